@@ -0,0 +1,158 @@
+package knn
+
+import (
+	"errors"
+	"math"
+)
+
+// BallTree is an exact L1/L2 index. Every node stores a center and the L1
+// and Euclidean radii of its rows, so a subtree is skipped when the query
+// is farther from the ball than the current k-th distance.
+type BallTree[T float32 | float64] struct {
+	LeafSize int // rows per leaf (default = 16)
+
+	data  *Tensor[T]
+	rows  [][]T
+	ids   []int
+	nodes []ballNode[T]
+}
+
+// ballNode holds ids[lo:hi]. Leaves have left == -1.
+type ballNode[T float32 | float64] struct {
+	center      []T
+	radiusL1    T
+	radiusL2    T
+	left, right int
+	lo, hi      int
+}
+
+func (b *BallTree[T]) New(data *Tensor[T]) error {
+	rows, ids, err := treeRows(data)
+	if err != nil {
+		return err
+	}
+	if b.LeafSize <= 0 {
+		b.LeafSize = 16
+	}
+
+	b.data = data
+	b.rows = rows
+	b.ids = ids
+	b.nodes = nil
+	b.build(0, len(ids))
+
+	return nil
+}
+
+func (b *BallTree[T]) build(lo, hi int) int {
+	ids := b.ids[lo:hi]
+	center := make([]T, len(b.rows[0]))
+	for _, i := range ids {
+		for j, x := range b.rows[i] {
+			center[j] += x
+		}
+	}
+	for j := range center {
+		center[j] /= T(len(ids))
+	}
+
+	node := ballNode[T]{center: center, left: -1, right: -1, lo: lo, hi: hi}
+	for _, i := range ids {
+		node.radiusL1 = maxOf(node.radiusL1, manhattanUnrolled(center, b.rows[i]))
+		node.radiusL2 = maxOf(node.radiusL2, squaredEuclidean(center, b.rows[i]))
+	}
+	node.radiusL2 = T(math.Sqrt(float64(node.radiusL2)))
+
+	id := len(b.nodes)
+	b.nodes = append(b.nodes, node)
+	if hi-lo <= b.LeafSize {
+		return id
+	}
+
+	widest(b.rows, ids)
+	mid := (lo + hi) / 2
+	left := b.build(lo, mid)
+	right := b.build(mid, hi)
+	b.nodes[id].left, b.nodes[id].right = left, right
+
+	return id
+}
+
+// Search returns the k nearest rows. Values are L1 distances for L1 and
+// Euclidean distances for L2.
+func (b *BallTree[T]) Search(query *Tensor[T], k int, metric int) (Neighbors[T], error) {
+	if b.data == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+
+	t, err := newTreeSearch(b.data, query, metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+	if err := t.s.checker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	t.k = k
+	b.search(t, 0, b.gap(t, 0))
+
+	return t.neighbors(0)
+}
+
+// Range returns every row within radius of the query, nearest first. An
+// optional int caps the number of results.
+func (b *BallTree[T]) Range(query *Tensor[T], radius T, metric int, opts ...interface{}) (Neighbors[T], error) {
+	if b.data == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+
+	t, err := newTreeSearch(b.data, query, metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+	if err := t.s.rangeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+	limit, err := rangeLimit("Range", opts)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	t.inRange(radius)
+	b.search(t, 0, b.gap(t, 0))
+
+	return t.neighbors(limit)
+}
+
+func (b *BallTree[T]) search(t *treeSearch[T], id int, gap T) {
+	if t.lower(gap) > t.bound() {
+		return
+	}
+
+	node := b.nodes[id]
+	if node.left < 0 {
+		t.scan(b.rows, b.ids[node.lo:node.hi])
+		return
+	}
+
+	near, far := node.left, node.right
+	nearGap, farGap := b.gap(t, near), b.gap(t, far)
+	if farGap < nearGap {
+		near, far = far, near
+		nearGap, farGap = farGap, nearGap
+	}
+
+	b.search(t, near, nearGap)
+	b.search(t, far, farGap)
+}
+
+// gap is the distance from the query to the ball of a node, a lower bound
+// on the distance to any of its rows.
+func (b *BallTree[T]) gap(t *treeSearch[T], id int) T {
+	node := b.nodes[id]
+	if t.metric == L1 {
+		return maxOf(0, manhattanUnrolled(t.query, node.center)-node.radiusL1)
+	}
+	d := T(math.Sqrt(float64(squaredEuclidean(t.query, node.center))))
+	return maxOf(0, d-node.radiusL2)
+}
@@ -0,0 +1,11 @@
+package knn
+
+import "testing"
+
+func TestBallTree(t *testing.T) {
+	testTree(t, &BallTree[float32]{LeafSize: 8})
+
+	if _, err := (&BallTree[float32]{}).Search(nil, 1, L2); err == nil {
+		t.Error("Expected error for empty index, got nil")
+	}
+}
@@ -0,0 +1,157 @@
+package knn
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"reflect"
+)
+
+// NewBinary wraps packed bit vectors: a []uint64 vector or a [][]uint64
+// matrix holding n bits per row, bit j at word j/64, position j%64.
+func (t *Tensor[T]) NewBinary(values interface{}, n int) error {
+	if n < 1 {
+		return errors.New("bit count must be greater than 0")
+	}
+	words := (n + 63) / 64
+
+	switch v := values.(type) {
+	case []uint64:
+		if len(v) != words {
+			return fmt.Errorf("expected %d words for %d bits, got %d", words, n, len(v))
+		}
+		t.Shape = [2]int{n, 0}
+		t.Rank = 1
+	case [][]uint64:
+		if len(v) < 1 {
+			return fmt.Errorf("empty values")
+		}
+		for i, row := range v {
+			if len(row) != words {
+				return fmt.Errorf("row %d: expected %d words for %d bits, got %d", i, words, n, len(row))
+			}
+		}
+		t.Shape = [2]int{len(v), n}
+		t.Rank = 2
+	default:
+		return fmt.Errorf("unsupported values: %T", values)
+	}
+
+	t.Values = values
+	t.Type = reflect.TypeOf(uint64(0))
+
+	return nil
+}
+
+// Binarize returns a packed bit copy of the tensor where bit j is set when
+// x[j] > threshold. Binarize(0) binarizes by sign.
+func (t *Tensor[T]) Binarize(threshold T) (*Tensor[T], error) {
+	b := &Tensor[T]{}
+
+	if values, ok := t.Values.([]T); ok {
+		return b, b.NewBinary(binarize(values, threshold), len(values))
+	}
+	if t.Rank != 2 || !t.dense() {
+		return nil, fmt.Errorf("unsupported values: %T", t.Values)
+	}
+
+	rows := t.rows()
+	packed := make([][]uint64, len(rows))
+	for i, row := range rows {
+		packed[i] = binarize(row, threshold)
+	}
+	return b, b.NewBinary(packed, t.Shape[1])
+}
+
+func binarize[T float32 | float64](v []T, threshold T) []uint64 {
+	packed := make([]uint64, (len(v)+63)/64)
+	for j, x := range v {
+		if x > threshold {
+			packed[j/64] |= 1 << uint(j%64)
+		}
+	}
+	return packed
+}
+
+func (s *Search[T]) binary() bool {
+	if s.Data == nil || s.Query == nil {
+		return false
+	}
+	_, data := s.Data.Values.([][]uint64)
+	_, query := s.Query.Values.([]uint64)
+	return data || query
+}
+
+// hammingBits is the Hamming search over packed bit tensors.
+func (s *Search[T]) hammingBits(k int) (Neighbors[T], error) {
+	if err := s.shapeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	if err := s.kChecker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	query, ok := s.Query.Values.([]uint64)
+	data, ok2 := s.Data.Values.([][]uint64)
+	if !ok || !ok2 {
+		return Neighbors[T]{}, errors.New("data and query must both be binary")
+	}
+
+	if s.Weights != nil {
+		return Neighbors[T]{}, errors.New("weights are not supported for binary tensors")
+	}
+
+	return s.scan(k, func(i int) T {
+		return T(popcount(query, data[i]))
+	})
+}
+
+// batchHamming runs hammingBits for every row of a packed bit query
+// matrix.
+func (s *Search[T]) batchHamming(k int) ([]Neighbors[T], error) {
+	if s.Query == nil || s.Query.Rank != 2 {
+		return nil, errors.New("data and query must be matrices")
+	}
+	queries, ok := s.Query.Values.([][]uint64)
+	if !ok {
+		return nil, errors.New("data and query must both be binary")
+	}
+
+	results := make([]Neighbors[T], len(queries))
+	for q, row := range queries {
+		c := *s
+		c.Query = &Tensor[T]{
+			Values: row,
+			Shape:  [2]int{s.Query.Shape[1]},
+			Type:   s.Query.Type,
+			Rank:   1,
+		}
+
+		nn, err := c.hammingBits(k)
+		if err != nil {
+			return nil, err
+		}
+		results[q] = nn
+	}
+
+	return results, nil
+}
+
+func popcount(query, data []uint64) int {
+	count := 0
+	n := len(query)
+
+	for j := 0; j < n-3; j += 4 {
+		count += bits.OnesCount64(query[j]^data[j]) +
+			bits.OnesCount64(query[j+1]^data[j+1]) +
+			bits.OnesCount64(query[j+2]^data[j+2]) +
+			bits.OnesCount64(query[j+3]^data[j+3])
+	}
+
+	for j := n - n%4; j < n; j++ {
+		count += bits.OnesCount64(query[j] ^ data[j])
+	}
+
+	return count
+}
@@ -0,0 +1,189 @@
+package knn
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBinarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     interface{}
+		threshold float32
+		expected  interface{}
+		shape     [2]int
+	}{
+		{"Sign", []float32{1, -1, 0, 2}, 0, []uint64{0b1001}, [2]int{4, 0}},
+		{"Threshold", []float32{0.5, 1.5, 2.5}, 1, []uint64{0b110}, [2]int{3, 0}},
+		{"Matrix", [][]float32{{1, -1}, {-1, 1}}, 0, [][]uint64{{0b01}, {0b10}}, [2]int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tensor := &Tensor[float32]{}
+			_ = tensor.New(tt.input)
+
+			b, err := tensor.Binarize(tt.threshold)
+			if err != nil {
+				t.Fatalf("Binarize failed: %v", err)
+			}
+			if !reflect.DeepEqual(b.Values, tt.expected) {
+				t.Errorf("Binarize() = %v, want %v", b.Values, tt.expected)
+			}
+			if b.Shape != tt.shape {
+				t.Errorf("Shape = %v, want %v", b.Shape, tt.shape)
+			}
+		})
+	}
+
+	t.Run("Wide", func(t *testing.T) {
+		v := make([]float64, 130)
+		v[0], v[64], v[129] = 1, 1, 1
+		tensor := &Tensor[float64]{}
+		_ = tensor.New(v)
+
+		b, _ := tensor.Binarize(0)
+		words := b.Values.([]uint64)
+		if len(words) != 3 || words[0] != 1 || words[1] != 1 || words[2] != 2 {
+			t.Errorf("Binarize() = %v", words)
+		}
+	})
+}
+
+func TestNewBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  interface{}
+		bits    int
+		wantErr bool
+	}{
+		{"Vector", []uint64{1, 2}, 100, false},
+		{"Matrix", [][]uint64{{1}, {2}}, 64, false},
+		{"Zero bits", []uint64{1}, 0, true},
+		{"Word mismatch", []uint64{1}, 65, true},
+		{"Ragged", [][]uint64{{1}, {1, 2}}, 64, true},
+		{"Empty", [][]uint64{}, 64, true},
+		{"Unsupported", []uint32{1}, 32, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Tensor[float32]{}
+			if err := b.NewBinary(tt.values, tt.bits); (err != nil) != tt.wantErr {
+				t.Errorf("NewBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHammingBits(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([][]float32, 300)
+	for i := range data {
+		data[i] = make([]float32, 200)
+		for j := range data[i] {
+			data[i][j] = float32(r.Intn(2))
+		}
+	}
+	query := append([]float32{}, data[7]...)
+	query[0], query[1] = 1-query[0], 1-query[1]
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+
+	packedData, _ := dataTensor.Binarize(0.5)
+	packedQuery, _ := queryTensor.Binarize(0.5)
+
+	for _, multithread := range []bool{false, true} {
+		exact := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		want, _ := exact.Hamming(10)
+
+		s := &Search[float32]{Data: packedData, Query: packedQuery, Multithread: multithread}
+		got, err := s.Hamming(10)
+		if err != nil {
+			t.Fatalf("Hamming failed: %v", err)
+		}
+
+		if got.Indices[0] != 7 || got.Values[0] != 2 {
+			t.Errorf("Nearest = %d (%v), want 7 (2)", got.Indices[0], got.Values[0])
+		}
+		if !reflect.DeepEqual(got.Values, want.Values) {
+			t.Errorf("Values = %v, want %v", got.Values, want.Values)
+		}
+	}
+
+	t.Run("Filter", func(t *testing.T) {
+		s := &Search[float32]{Data: packedData, Query: packedQuery, Filter: func(i int) bool { return i != 7 }}
+		got, _ := s.Hamming(1)
+		if got.Indices[0] == 7 {
+			t.Error("Filtered row returned")
+		}
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		idx := &Index[float32]{}
+		if err := idx.New(packedData); err != nil {
+			t.Fatalf("Index.New failed: %v", err)
+		}
+		got, err := idx.Search(packedQuery, 1, Hamming)
+		if err != nil || got.Indices[0] != 7 {
+			t.Errorf("Index.Search() = %v, %v", got.Indices, err)
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		queries := &Tensor[float32]{}
+		_ = queries.New(data[:5])
+		packedQueries, _ := queries.Binarize(0.5)
+
+		idx := &Index[float32]{}
+		_ = idx.New(packedData)
+		got, err := idx.BatchSearch(packedQueries, 3, Hamming)
+		if err != nil {
+			t.Fatalf("BatchSearch failed: %v", err)
+		}
+		for q := range data[:5] {
+			single := &Tensor[float32]{}
+			_ = single.NewBinary(packedQueries.Values.([][]uint64)[q], 200)
+			want, _ := idx.Search(single, 3, Hamming)
+			if !reflect.DeepEqual(got[q], want) {
+				t.Errorf("Query %d: got %v, want %v", q, got[q], want)
+			}
+		}
+
+		float := &Search[float32]{Data: dataTensor, Query: packedQueries}
+		if _, err := float.BatchL2(1); err == nil {
+			t.Error("BatchL2 with binary queries: expected error, got nil")
+		}
+		if _, err := float.batch(1, Chebyshev); err == nil {
+			t.Error("Chebyshev batch with binary queries: expected error, got nil")
+		}
+		if _, err := float.batch(1, Hamming); err == nil {
+			t.Error("Hamming batch with binary queries: expected error, got nil")
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		errorCases := []struct {
+			name string
+			s    *Search[float32]
+			run  func(s *Search[float32]) error
+		}{
+			{"L2 on binary", &Search[float32]{Data: packedData, Query: packedQuery}, func(s *Search[float32]) error { _, err := s.L2(1); return err }},
+			{"Mixed query", &Search[float32]{Data: packedData, Query: queryTensor}, func(s *Search[float32]) error { _, err := s.Hamming(1); return err }},
+			{"Mixed data", &Search[float32]{Data: dataTensor, Query: packedQuery}, func(s *Search[float32]) error { _, err := s.L1(1); return err }},
+			{"Invalid k", &Search[float32]{Data: packedData, Query: packedQuery}, func(s *Search[float32]) error { _, err := s.Hamming(0); return err }},
+		}
+
+		for _, tt := range errorCases {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := tt.run(tt.s); err == nil {
+					t.Error("Expected error, got nil")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package knn
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+const (
+	Majority = iota
+	DistanceWeighted
+)
+
+type Classifier[T float32 | float64, L comparable] struct {
+	Search *Search[T]
+	Labels []L // one label per row of Search.Data
+	K      int
+	Metric int // L1, L2, MIPS, ...
+	Voting int // Majority or DistanceWeighted
+
+	mu sync.Mutex // guards the caches of Search between predictions
+}
+
+type Regressor[T float32 | float64] struct {
+	Search  *Search[T]
+	Targets []T // one target per row of Search.Data
+	K       int
+	Metric  int
+	Voting  int
+
+	mu sync.Mutex // guards the caches of Search between predictions
+}
+
+func (c *Classifier[T, L]) Predict(query *Tensor[T]) (L, error) {
+	var label L
+
+	order, scores, err := c.votes(query)
+	if err != nil {
+		return label, err
+	}
+
+	// ties go to the label whose first neighbor is closest
+	best := math.Inf(-1)
+	for _, l := range order {
+		if scores[l] > best {
+			best = scores[l]
+			label = l
+		}
+	}
+
+	return label, nil
+}
+
+// PredictProba returns the share of the vote for every label found among
+// the k nearest neighbors.
+func (c *Classifier[T, L]) PredictProba(query *Tensor[T]) (map[L]float64, error) {
+	_, scores, err := c.votes(query)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	for l := range scores {
+		scores[l] /= total
+	}
+
+	return scores, nil
+}
+
+func (c *Classifier[T, L]) votes(query *Tensor[T]) ([]L, map[L]float64, error) {
+	if c.Search == nil || c.Search.Data == nil {
+		return nil, nil, errors.New("classifier search must be initialized")
+	}
+	if len(c.Labels) != c.Search.Data.Shape[0] {
+		return nil, nil, errors.New("labels and data lengths do not match")
+	}
+
+	nn, weights, err := vote(&c.mu, c.Search, query, c.K, c.Metric, c.Voting)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var order []L
+	scores := make(map[L]float64)
+	for i, index := range nn.Indices {
+		l := c.Labels[index]
+		if _, ok := scores[l]; !ok {
+			order = append(order, l)
+		}
+		scores[l] += weights[i]
+	}
+
+	return order, scores, nil
+}
+
+func (r *Regressor[T]) Predict(query *Tensor[T]) (T, error) {
+	if r.Search == nil || r.Search.Data == nil {
+		return 0, errors.New("regressor search must be initialized")
+	}
+	if len(r.Targets) != r.Search.Data.Shape[0] {
+		return 0, errors.New("targets and data lengths do not match")
+	}
+
+	nn, weights, err := vote(&r.mu, r.Search, query, r.K, r.Metric, r.Voting)
+	if err != nil {
+		return 0, err
+	}
+
+	var sum, total float64
+	for i, index := range nn.Indices {
+		sum += weights[i] * float64(r.Targets[index])
+		total += weights[i]
+	}
+
+	return T(sum / total), nil
+}
+
+// vote runs the search on a copy of s and returns a positive weight per
+// neighbor. Caches built by the search (row norms, whitening) are copied
+// back to s under mu, so later predictions reuse them.
+func vote[T float32 | float64](mu *sync.Mutex, s *Search[T], query *Tensor[T], k int, metric int, voting int) (Neighbors[T], []float64, error) {
+	mu.Lock()
+	c := *s
+	mu.Unlock()
+
+	c.Query = query
+	nn, err := c.metric(k, metric)
+
+	mu.Lock()
+	s.keepCaches(&c)
+	mu.Unlock()
+
+	if err != nil {
+		return Neighbors[T]{}, nil, err
+	}
+
+	weights := make([]float64, len(nn.Indices))
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	switch voting {
+	case Majority:
+		return nn, weights, nil
+	case DistanceWeighted:
+	default:
+		return Neighbors[T]{}, nil, errors.New("unsupported voting")
+	}
+
+	if metric == MIPS || metric == Cosine {
+		// similarities, negative scores get no say
+		total := 0.0
+		for i, v := range nn.Values {
+			weights[i] = math.Max(float64(v), 0)
+			total += weights[i]
+		}
+		if total == 0 {
+			for i := range weights {
+				weights[i] = 1
+			}
+		}
+		return nn, weights, nil
+	}
+
+	distances := make([]float64, len(nn.Values))
+	for i, v := range nn.Values {
+		distances[i] = float64(v)
+	}
+	if metric == L2 {
+		// L2 ranks by |x|²/2 - q·x, which cancels for close rows, so
+		// measure the winners exactly
+		q := query.Values.([]T)
+		for i, index := range nn.Indices {
+			distances[i] = math.Sqrt(c.squaredDistance(q, index))
+		}
+	}
+
+	// exact matches take the whole vote
+	exact := false
+	for _, d := range distances {
+		if d == 0 {
+			exact = true
+		}
+	}
+	for i, d := range distances {
+		switch {
+		case exact && d == 0:
+			weights[i] = 1
+		case exact:
+			weights[i] = 0
+		default:
+			weights[i] = 1 / d
+		}
+	}
+
+	return nn, weights, nil
+}
@@ -0,0 +1,244 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestClassifier(t *testing.T) {
+	data := [][]float32{
+		{0.0, 0.0},
+		{0.0, 1.0},
+		{1.0, 0.0},
+		{5.0, 5.0},
+		{5.0, 6.0},
+		{3.0, 3.0},
+	}
+	labels := []string{"a", "a", "a", "b", "b", "b"}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	query := func(v ...float32) *Tensor[float32] {
+		q := &Tensor[float32]{}
+		_ = q.New(v)
+		return q
+	}
+
+	t.Run("Majority", func(t *testing.T) {
+		c := &Classifier[float32, string]{
+			Search: &Search[float32]{Data: dataTensor},
+			Labels: labels,
+			K:      3,
+			Metric: L2,
+		}
+
+		label, err := c.Predict(query(0.5, 0.5))
+		if err != nil {
+			t.Fatalf("Predict failed: %v", err)
+		}
+		if label != "a" {
+			t.Errorf("Expected label a, got %v", label)
+		}
+
+		label, _ = c.Predict(query(4.5, 5.0))
+		if label != "b" {
+			t.Errorf("Expected label b, got %v", label)
+		}
+		if c.Search.Query != nil {
+			t.Errorf("Predict changed the caller's search query to %v", c.Search.Query.Values)
+		}
+
+		proba, err := c.PredictProba(query(2.0, 2.0))
+		if err != nil {
+			t.Fatalf("PredictProba failed: %v", err)
+		}
+		expected := map[string]float64{"a": 2.0 / 3, "b": 1.0 / 3}
+		for l, p := range expected {
+			if math.Abs(proba[l]-p) > 1e-9 {
+				t.Errorf("Probability of %v: expected %v, got %v", l, p, proba[l])
+			}
+		}
+	})
+
+	t.Run("Distance Weighted", func(t *testing.T) {
+		// two far "a" neighbors against one close "b" neighbor
+		c := &Classifier[float32, string]{
+			Search: &Search[float32]{Data: dataTensor},
+			Labels: labels,
+			K:      3,
+			Metric: L1,
+		}
+
+		label, _ := c.Predict(query(2.6, 2.6))
+		if label != "a" {
+			t.Errorf("Majority: expected label a, got %v", label)
+		}
+
+		c.Voting = DistanceWeighted
+		label, err := c.Predict(query(2.6, 2.6))
+		if err != nil {
+			t.Fatalf("Predict failed: %v", err)
+		}
+		if label != "b" {
+			t.Errorf("DistanceWeighted: expected label b, got %v", label)
+		}
+
+		proba, _ := c.PredictProba(query(3.0, 3.0))
+		if !reflect.DeepEqual(proba, map[string]float64{"a": 0, "b": 1}) {
+			t.Errorf("Exact match should take the whole vote, got %v", proba)
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		c := &Classifier[float32, string]{
+			Search: &Search[float32]{Data: dataTensor},
+			Labels: labels[:2],
+			K:      3,
+			Metric: L2,
+		}
+		if _, err := c.Predict(query(0.0, 0.0)); err == nil {
+			t.Error("Expected error for mismatched labels, got nil")
+		}
+
+		c.Labels = labels
+		c.Voting = -1
+		if _, err := c.Predict(query(0.0, 0.0)); err == nil {
+			t.Error("Expected error for unsupported voting, got nil")
+		}
+
+		c.Voting = Majority
+		c.K = 0
+		if _, err := c.PredictProba(query(0.0, 0.0)); err == nil {
+			t.Error("Expected error for k=0, got nil")
+		}
+	})
+}
+
+func TestRegressor(t *testing.T) {
+	data := [][]float64{{0.0}, {1.0}, {2.0}, {10.0}}
+	targets := []float64{0.0, 10.0, 20.0, 100.0}
+
+	dataTensor := &Tensor[float64]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float64]{}
+	_ = queryTensor.New([]float64{0.5})
+
+	r := &Regressor[float64]{
+		Search:  &Search[float64]{Data: dataTensor},
+		Targets: targets,
+		K:       3,
+		Metric:  L2,
+	}
+
+	prediction, err := r.Predict(queryTensor)
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	if math.Abs(prediction-10.0) > 1e-9 {
+		t.Errorf("Majority: expected 10, got %v", prediction)
+	}
+
+	r.Voting = DistanceWeighted
+	prediction, err = r.Predict(queryTensor)
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	// weights 1/0.5, 1/0.5, 1/1.5
+	expected := (2*0.0 + 2*10.0 + 20.0/1.5) / (2 + 2 + 1/1.5)
+	if math.Abs(prediction-expected) > 1e-9 {
+		t.Errorf("DistanceWeighted: expected %v, got %v", expected, prediction)
+	}
+
+	r.Targets = targets[:1]
+	if _, err := r.Predict(queryTensor); err == nil {
+		t.Error("Expected error for mismatched targets, got nil")
+	}
+}
+
+func TestRegressorExactMatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([][]float32, 50)
+	targets := make([]float32, len(data))
+	for i := range data {
+		data[i] = make([]float32, 64)
+		for j := range data[i] {
+			data[i][j] = 100 + 1000*r.Float32()
+		}
+		targets[i] = 100
+	}
+	targets[17] = 1
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(append([]float32{}, data[17]...))
+
+	reg := &Regressor[float32]{
+		Search:  &Search[float32]{Data: dataTensor},
+		Targets: targets,
+		K:       5,
+		Metric:  L2,
+		Voting:  DistanceWeighted,
+	}
+
+	prediction, err := reg.Predict(queryTensor)
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	if prediction != 1 {
+		t.Errorf("Expected the exact match target 1, got %v", prediction)
+	}
+}
+
+func TestVoteCaches(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(randomMatrix(r, 100, 4))
+	labels := make([]int, 100)
+	for i := range labels {
+		labels[i] = i % 3
+	}
+	queries := randomMatrix(r, 8, 4)
+
+	for _, metric := range []int{Mahalanobis, Cosine} {
+		c := &Classifier[float32, int]{
+			Search: &Search[float32]{Data: dataTensor},
+			Labels: labels,
+			K:      5,
+			Metric: metric,
+		}
+
+		var wg sync.WaitGroup
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := c.Predict(queryTensor); err != nil {
+					t.Errorf("Predict failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if metric == Mahalanobis && c.Search.whitened == nil {
+			t.Error("Whitening was not kept after Predict")
+		}
+		if metric == Cosine && c.Search.norms == nil {
+			t.Error("Row norms were not kept after Predict")
+		}
+
+		whitened, norms := c.Search.whitened, c.Search.norms
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+		_, _ = c.Predict(queryTensor)
+		if c.Search.whitened != whitened || (norms != nil && &c.Search.norms[0] != &norms[0]) {
+			t.Error("Caches were rebuilt by a later Predict")
+		}
+	}
+}
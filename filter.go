@@ -0,0 +1,69 @@
+package knn
+
+import (
+	"math/bits"
+)
+
+// Bitset is a fixed size set of row indices. Its Has method can be used
+// directly as a Search.Filter allow-list:
+//
+//	s.Filter = allow.Has
+//	s.Filter = func(i int) bool { return !deny.Has(i) }
+type Bitset []uint64
+
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
+
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b Bitset) Clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+func (b Bitset) Has(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (b Bitset) Count() int {
+	count := 0
+	for _, word := range b {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+func (s *Search[T]) keep(i int) bool {
+	return s.Filter == nil || s.Filter(i)
+}
+
+// kept counts the data rows passing the filter.
+func (s *Search[T]) kept() int {
+	count := 0
+	for i := 0; i < s.Data.Shape[0]; i++ {
+		if s.keep(i) {
+			count++
+		}
+	}
+	return count
+}
+
+// bins counts the bins of bs consecutive rows holding at least one row that
+// passes the filter.
+func (s *Search[T]) bins(bs int) int {
+	count := 0
+	for lo := 0; lo < s.Data.Shape[0]; lo += bs {
+		for i := lo; i < min(lo+bs, s.Data.Shape[0]); i++ {
+			if s.keep(i) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,102 @@
+package knn
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	b := NewBitset(130)
+	if len(b) != 3 {
+		t.Fatalf("Expected 3 words, got %d", len(b))
+	}
+
+	for _, i := range []int{0, 63, 64, 129} {
+		b.Set(i)
+	}
+	b.Clear(63)
+
+	for i, want := range map[int]bool{0: true, 1: false, 63: false, 64: true, 129: true, 130: false, -1: false} {
+		if got := b.Has(i); got != want {
+			t.Errorf("Has(%d) = %v, want %v", i, got, want)
+		}
+	}
+
+	if b.Count() != 3 {
+		t.Errorf("Expected count 3, got %d", b.Count())
+	}
+}
+
+func TestFilteredSearch(t *testing.T) {
+	data := [][]float32{
+		{1.0, 2.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{7.0, 8.0, 9.0},
+		{10.0, 11.0, 12.0},
+		{3.0, 4.0, 5.0},
+	}
+	query := []float32{3.0, 4.0, 5.0}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+
+	// deny the exact match and the best inner product
+	deny := NewBitset(len(data))
+	deny.Set(4)
+	deny.Set(3)
+
+	tests := []struct {
+		name            string
+		search          func(s *Search[float32]) (Neighbors[float32], error)
+		expectedIndices []int
+	}{
+		{"L1", func(s *Search[float32]) (Neighbors[float32], error) { return s.L1(2) }, []int{1, 0}},
+		{"L2", func(s *Search[float32]) (Neighbors[float32], error) { return s.L2(2) }, []int{1, 0}},
+		{"MIPS", func(s *Search[float32]) (Neighbors[float32], error) { return s.MIPS(2) }, []int{2, 1}},
+		{"Cosine", func(s *Search[float32]) (Neighbors[float32], error) { return s.Cosine(2) }, []int{1, 2}},
+		{"RangeL1", func(s *Search[float32]) (Neighbors[float32], error) { return s.RangeL1(100) }, []int{1, 0, 2}},
+	}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        dataTensor,
+			Query:       queryTensor,
+			Multithread: multithread,
+			Filter:      func(i int) bool { return !deny.Has(i) },
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s multithread=%v", tt.name, multithread), func(t *testing.T) {
+				neighbors, err := tt.search(s)
+				if err != nil {
+					t.Fatalf("%s search failed: %v", tt.name, err)
+				}
+				if !reflect.DeepEqual(neighbors.Indices, tt.expectedIndices) {
+					t.Errorf("Indices mismatch. Got %v, want %v", neighbors.Indices, tt.expectedIndices)
+				}
+			})
+		}
+	}
+
+	t.Run("Allow-list", func(t *testing.T) {
+		allow := NewBitset(len(data))
+		allow.Set(2)
+		allow.Set(3)
+
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: allow.Has}
+		neighbors, err := s.L1(2)
+		if err != nil {
+			t.Fatalf("L1 search failed: %v", err)
+		}
+		if !reflect.DeepEqual(neighbors.Indices, []int{2, 3}) {
+			t.Errorf("Indices mismatch. Got %v, want %v", neighbors.Indices, []int{2, 3})
+		}
+
+		if _, err := s.L2(3); err == nil {
+			t.Error("Expected error for k greater than the filtered count, got nil")
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Forest is a forest of random projection trees, like Annoy
+// (https://github.com/spotify/annoy). Each node splits its rows by the
+// hyperplane equidistant from two random rows. A query walks all trees at
+// once, following the closest splits first, and re-ranks SearchK candidates.
+type Forest[T float32 | float64] struct {
+	Trees    int   // number of trees (default = 10)
+	LeafSize int   // rows per leaf (default = 16)
+	SearchK  int   // candidates re-ranked per query (default = Trees * k)
+	Metric   int   // L2 or Cosine
+	Seed     int64 // seed for the splits
+
+	data  *Tensor[T]
+	rows  [][]T
+	norms []T
+	nodes []forestNode[T]
+	roots []int
+}
+
+// forestNode is a leaf when Items is not nil. Rows x with
+// Normal·x + Offset > 0 go Right.
+type forestNode[T float32 | float64] struct {
+	Normal      []T
+	Offset      T
+	Left, Right int
+	Items       []int
+}
+
+func (f *Forest[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+	if f.Metric != L2 && f.Metric != Cosine {
+		return fmt.Errorf("unsupported metric: %d", f.Metric)
+	}
+	if err := f.load(data); err != nil {
+		return err
+	}
+	if f.Trees <= 0 {
+		f.Trees = 10
+	}
+	if f.LeafSize <= 0 {
+		f.LeafSize = 16
+	}
+
+	rng := rand.New(rand.NewSource(f.Seed))
+	items := make([]int, len(f.rows))
+	for i := range items {
+		items[i] = i
+	}
+
+	f.nodes = nil
+	f.roots = make([]int, f.Trees)
+	for t := range f.roots {
+		f.roots[t] = f.build(append([]int{}, items...), rng)
+	}
+
+	return nil
+}
+
+func (f *Forest[T]) load(data *Tensor[T]) error {
+	if !data.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+
+	f.data = data
+	f.rows = rows
+	f.norms = make([]T, len(rows))
+	for i, row := range rows {
+		f.norms[i] = norm(row)
+	}
+
+	return nil
+}
+
+func (f *Forest[T]) build(items []int, rng *rand.Rand) int {
+	if len(items) <= f.LeafSize {
+		f.nodes = append(f.nodes, forestNode[T]{Items: items})
+		return len(f.nodes) - 1
+	}
+
+	normal, offset := f.split(items, rng)
+
+	var left, right []int
+	for _, i := range items {
+		if dot(normal, f.rows[i])+offset > 0 {
+			right = append(right, i)
+		} else {
+			left = append(left, i)
+		}
+	}
+
+	// duplicate rows can't be separated by a hyperplane, split them at random
+	if len(left) == 0 || len(right) == 0 {
+		normal = nil
+		rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+		left, right = items[:len(items)/2], items[len(items)/2:]
+	}
+
+	id := len(f.nodes)
+	f.nodes = append(f.nodes, forestNode[T]{Normal: normal, Offset: offset})
+	l := f.build(left, rng)
+	r := f.build(right, rng)
+	f.nodes[id].Left, f.nodes[id].Right = l, r
+
+	return id
+}
+
+// split returns the hyperplane equidistant from two random rows, using
+// their directions for Cosine.
+func (f *Forest[T]) split(items []int, rng *rand.Rand) ([]T, T) {
+	a := rng.Intn(len(items))
+	b := rng.Intn(len(items) - 1)
+	if b >= a {
+		b++
+	}
+	p, q := f.rows[items[a]], f.rows[items[b]]
+
+	normal := make([]T, len(p))
+	if f.Metric == Cosine {
+		pn, qn := f.norms[items[a]], f.norms[items[b]]
+		for j := range normal {
+			normal[j] = ratio(p[j], pn) - ratio(q[j], qn)
+		}
+		return normal, 0
+	}
+
+	var offset T
+	for j := range normal {
+		normal[j] = p[j] - q[j]
+		offset -= normal[j] * (p[j] + q[j]) / 2
+	}
+	return normal, offset
+}
+
+// Search returns up to k rows, nearest first. Values are Euclidean
+// distances for L2 and similarities, highest first, for Cosine.
+func (f *Forest[T]) Search(query *Tensor[T], k int) (Neighbors[T], error) {
+	if f.roots == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+	if query == nil || query.Rank != 1 {
+		return Neighbors[T]{}, errors.New("query must be a vector")
+	}
+	if query.Shape[0] != f.data.Shape[1] {
+		return Neighbors[T]{}, errors.New("data and query dimensions do not match")
+	}
+	if k <= 0 {
+		return Neighbors[T]{}, errors.New("k must be greater than 0")
+	}
+
+	vector := query.Values.([]T)
+	searchK := f.SearchK
+	if searchK <= 0 {
+		searchK = len(f.roots) * k
+	}
+
+	q := &forestQueue{}
+	for _, root := range f.roots {
+		heap.Push(q, forestEntry{node: root, margin: math.Inf(1)})
+	}
+
+	seen := NewBitset(len(f.rows))
+	var candidates []int
+	for q.Len() > 0 && len(candidates) < searchK {
+		entry := heap.Pop(q).(forestEntry)
+		node := f.nodes[entry.node]
+
+		if node.Items != nil {
+			for _, i := range node.Items {
+				if !seen.Has(i) {
+					seen.Set(i)
+					candidates = append(candidates, i)
+				}
+			}
+			continue
+		}
+
+		if node.Normal == nil {
+			heap.Push(q, forestEntry{node: node.Left, margin: entry.margin})
+			heap.Push(q, forestEntry{node: node.Right, margin: entry.margin})
+			continue
+		}
+
+		margin := float64(dot(node.Normal, vector) + node.Offset)
+		heap.Push(q, forestEntry{node: node.Right, margin: math.Min(entry.margin, margin)})
+		heap.Push(q, forestEntry{node: node.Left, margin: math.Min(entry.margin, -margin)})
+	}
+
+	// ties keep the lowest index, like a brute-force scan
+	sort.Ints(candidates)
+
+	qnorm := norm(vector)
+	h := &MaxHeap[T]{}
+	heap.Init(h)
+	for _, i := range candidates {
+		var distance T
+		if f.Metric == L2 {
+			distance = squaredEuclidean(vector, f.rows[i])
+		} else if qnorm != 0 && f.norms[i] != 0 {
+			distance = -dot(vector, f.rows[i]) / (qnorm * f.norms[i])
+		}
+		h.Process(&i, &k, &distance)
+	}
+
+	n := h.Len()
+	nn, err := (&Search[T]{}).ret(&n, h)
+	if err != nil {
+		return nn, err
+	}
+	if f.Metric == Cosine {
+		return negate(nn, nil)
+	}
+
+	for i, v := range nn.Values {
+		nn.Values[i] = T(math.Sqrt(float64(v)))
+	}
+
+	return nn, nil
+}
+
+// forestQueue pops the node with the largest margin first.
+type forestQueue []forestEntry
+
+type forestEntry struct {
+	node   int
+	margin float64
+}
+
+func (q forestQueue) Len() int            { return len(q) }
+func (q forestQueue) Less(i, j int) bool  { return q[i].margin > q[j].margin }
+func (q forestQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *forestQueue) Push(x interface{}) { *q = append(*q, x.(forestEntry)) }
+func (q *forestQueue) Pop() interface{} {
+	x := (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return x
+}
+
+func (f *Forest[T]) GobEncode() ([]byte, error) {
+	if f.roots == nil {
+		return nil, errors.New("index must be initialized with New")
+	}
+
+	var data struct {
+		Trees    int
+		LeafSize int
+		SearchK  int
+		Metric   int
+		Seed     int64
+		Data     *Tensor[T]
+		Nodes    []forestNode[T]
+		Roots    []int
+	}
+
+	data.Trees = f.Trees
+	data.LeafSize = f.LeafSize
+	data.SearchK = f.SearchK
+	data.Metric = f.Metric
+	data.Seed = f.Seed
+	data.Data = f.data
+	data.Nodes = f.nodes
+	data.Roots = f.roots
+
+	return gobEncode(data)
+}
+
+func (f *Forest[T]) GobDecode(buf []byte) error {
+	var data struct {
+		Trees    int
+		LeafSize int
+		SearchK  int
+		Metric   int
+		Seed     int64
+		Data     *Tensor[T]
+		Nodes    []forestNode[T]
+		Roots    []int
+	}
+
+	if err := gobDecode(buf, &data); err != nil {
+		return err
+	}
+	if data.Data == nil {
+		return errors.New("missing data tensor")
+	}
+	if err := f.load(data.Data); err != nil {
+		return err
+	}
+
+	f.Trees = data.Trees
+	f.LeafSize = data.LeafSize
+	f.SearchK = data.SearchK
+	f.Metric = data.Metric
+	f.Seed = data.Seed
+	f.nodes = data.Nodes
+	f.roots = data.Roots
+
+	return nil
+}
+
+// ExportForest writes the forest together with its data tensor, so it can
+// be built offline and loaded with ImportForest.
+func ExportForest[T float32 | float64](f *Forest[T], filename string) error {
+	return exportGob(filename, "forest", f)
+}
+
+func ImportForest[T float32 | float64](filename string) (*Forest[T], error) {
+	var f Forest[T]
+	if err := importGob(filename, "forest", &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
@@ -0,0 +1,134 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestForest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1000, 8)
+	data = append(data, data[0], data[0], data[0]) // duplicates force random splits
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	queries := randomMatrix(r, 20, 8)
+
+	t.Run("Full search is exact", func(t *testing.T) {
+		for _, metric := range []int{L2, Cosine} {
+			f := &Forest[float32]{Trees: 4, LeafSize: 8, SearchK: len(data), Metric: metric, Seed: 1}
+			if err := f.New(dataTensor); err != nil {
+				t.Fatalf("Failed to build forest: %v", err)
+			}
+
+			for _, q := range queries {
+				queryTensor := &Tensor[float32]{}
+				_ = queryTensor.New(q)
+
+				got, err := f.Search(queryTensor, 5)
+				if err != nil {
+					t.Fatalf("Forest search failed: %v", err)
+				}
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+				want, _ := s.metric(5, metric)
+				if !reflect.DeepEqual(got.Indices, want.Indices) {
+					t.Errorf("Metric %d indices mismatch. Got %v, want %v", metric, got.Indices, want.Indices)
+				}
+
+				for i, index := range got.Indices {
+					expected := math.Sqrt(float64(squaredEuclidean(q, data[index])))
+					if metric == Cosine {
+						expected = float64(want.Values[i])
+					}
+					if math.Abs(float64(got.Values[i])-expected) > 1e-4 {
+						t.Errorf("Metric %d value at %d mismatch. Got %f, want %f", metric, i, got.Values[i], expected)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Recall", func(t *testing.T) {
+		f := &Forest[float32]{Trees: 10, SearchK: 200, Metric: L2, Seed: 1}
+		if err := f.New(dataTensor); err != nil {
+			t.Fatalf("Failed to build forest: %v", err)
+		}
+
+		total := 0.0
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+
+			got, err := f.Search(queryTensor, 10)
+			if err != nil {
+				t.Fatalf("Forest search failed: %v", err)
+			}
+			total += recall(t, dataTensor, queryTensor, got, 10)
+		}
+
+		if avg := total / float64(len(queries)); avg < 0.8 {
+			t.Errorf("Expected recall of at least 0.8, got %f", avg)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		f := &Forest[float32]{Trees: 5, Metric: Cosine, Seed: 1}
+		if err := f.New(dataTensor); err != nil {
+			t.Fatalf("Failed to build forest: %v", err)
+		}
+
+		filename := filepath.Join(t.TempDir(), "data.forest")
+		if err := ExportForest(f, filename); err != nil {
+			t.Fatalf("ExportForest failed: %v", err)
+		}
+		imported, err := ImportForest[float32](filename)
+		if err != nil {
+			t.Fatalf("ImportForest failed: %v", err)
+		}
+
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+
+			want, _ := f.Search(queryTensor, 10)
+			got, err := imported.Search(queryTensor, 10)
+			if err != nil {
+				t.Fatalf("Imported forest search failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Imported forest results differ. Got %v, want %v", got, want)
+			}
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+
+		if err := (&Forest[float32]{Metric: MIPS}).New(dataTensor); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+		if _, err := (&Forest[float32]{}).Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for empty index, got nil")
+		}
+		if err := ExportForest(&Forest[float32]{}, filepath.Join(t.TempDir(), "empty.forest")); err == nil {
+			t.Error("Expected error exporting empty index, got nil")
+		}
+
+		f := &Forest[float32]{Metric: L2}
+		_ = f.New(dataTensor)
+		if _, err := f.Search(queryTensor, 0); err == nil {
+			t.Error("Expected error for invalid k, got nil")
+		}
+		short := &Tensor[float32]{}
+		_ = short.New([]float32{1, 2})
+		if _, err := f.Search(short, 1); err == nil {
+			t.Error("Expected error for dimension mismatch, got nil")
+		}
+	})
+}
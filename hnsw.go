@@ -0,0 +1,278 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// HNSW is a Hierarchical Navigable Small World graph for approximate L2
+// search, see https://arxiv.org/abs/1603.09320
+type HNSW[T float32 | float64] struct {
+	M              int   // links per node, 2*M on the bottom layer (default = 16)
+	EfConstruction int   // candidate list size while inserting (default = 200)
+	EfSearch       int   // candidate list size while searching (default = 50)
+	Seed           int64 // seed for level generation
+
+	mu       sync.RWMutex
+	rows     [][]T
+	links    [][][]int // links[node][level]
+	entry    int
+	maxLevel int
+	rng      *rand.Rand
+}
+
+func (g *HNSW[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+	if !data.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+
+	g.rows = nil
+	g.links = nil
+	g.rng = nil
+
+	for _, row := range rows {
+		if _, err := g.insert(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Insert adds a copy of a vector to the graph and returns its index.
+func (g *HNSW[T]) Insert(vector []T) (int, error) {
+	return g.insert(append([]T(nil), vector...))
+}
+
+// insert adds a vector to the graph without copying it.
+func (g *HNSW[T]) insert(vector []T) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.rows) > 0 && len(vector) != len(g.rows[0]) {
+		return 0, errors.New("vector and data dimensions do not match")
+	}
+	if len(vector) == 0 {
+		return 0, errors.New("empty values")
+	}
+
+	g.defaults()
+
+	id := len(g.rows)
+	level := int(-math.Log(1-g.rng.Float64()) / math.Log(float64(g.M)))
+
+	g.rows = append(g.rows, vector)
+	g.links = append(g.links, make([][]int, level+1))
+
+	if id == 0 {
+		g.entry = 0
+		g.maxLevel = level
+		return id, nil
+	}
+
+	ep := g.entry
+	for l := g.maxLevel; l > level; l-- {
+		ep = g.greedy(vector, ep, l)
+	}
+
+	eps := []int{ep}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(vector, eps, g.EfConstruction, l)
+
+		neighbors := closest(candidates, g.M)
+		g.links[id][l] = neighbors
+		for _, n := range neighbors {
+			g.links[n][l] = append(g.links[n][l], id)
+			if len(g.links[n][l]) > g.maxLinks(l) {
+				g.shrink(n, l)
+			}
+		}
+
+		eps = eps[:0]
+		for _, c := range candidates {
+			eps = append(eps, c.Index)
+		}
+	}
+
+	if level > g.maxLevel {
+		g.entry = id
+		g.maxLevel = level
+	}
+
+	return id, nil
+}
+
+// Search returns the approximate k nearest rows with their Euclidean
+// distances, nearest first.
+func (g *HNSW[T]) Search(query *Tensor[T], k int) (Neighbors[T], error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.rows) == 0 {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+	if query == nil || query.Rank != 1 {
+		return Neighbors[T]{}, errors.New("query must be a vector")
+	}
+	if query.Shape[0] != len(g.rows[0]) {
+		return Neighbors[T]{}, errors.New("data and query dimensions do not match")
+	}
+	if k <= 0 || k > len(g.rows) {
+		return Neighbors[T]{}, errors.New("k must be greater than 0 and less than the length of the data")
+	}
+
+	vector := query.Values.([]T)
+
+	ep := g.entry
+	for l := g.maxLevel; l > 0; l-- {
+		ep = g.greedy(vector, ep, l)
+	}
+
+	candidates := g.searchLayer(vector, []int{ep}, max(g.EfSearch, k), 0)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	indices := make([]int, len(candidates))
+	values := make([]T, len(candidates))
+	for i, c := range candidates {
+		indices[i] = c.Index
+		values[i] = T(math.Sqrt(float64(c.Distance)))
+	}
+
+	return Neighbors[T]{Values: values, Indices: indices}, nil
+}
+
+func (g *HNSW[T]) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.rows)
+}
+
+func (g *HNSW[T]) defaults() {
+	if g.M <= 1 {
+		g.M = 16
+	}
+	if g.EfConstruction <= 0 {
+		g.EfConstruction = 200
+	}
+	if g.EfSearch <= 0 {
+		g.EfSearch = 50
+	}
+	if g.rng == nil {
+		g.rng = rand.New(rand.NewSource(g.Seed))
+	}
+}
+
+func (g *HNSW[T]) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * g.M
+	}
+	return g.M
+}
+
+// shrink keeps only the closest links of node n on level l.
+func (g *HNSW[T]) shrink(n int, l int) {
+	links := make([]Result[T], len(g.links[n][l]))
+	for i, m := range g.links[n][l] {
+		links[i] = Result[T]{Index: m, Distance: squaredEuclidean(g.rows[n], g.rows[m])}
+	}
+	sortResults(links)
+	g.links[n][l] = closest(links, g.maxLinks(l))
+}
+
+// greedy walks level l towards the query and returns the closest node found.
+func (g *HNSW[T]) greedy(query []T, ep int, l int) int {
+	best := squaredEuclidean(query, g.rows[ep])
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.links[ep][l] {
+			if d := squaredEuclidean(query, g.rows[n]); d < best {
+				best = d
+				ep = n
+				changed = true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes of level l closest to the query,
+// nearest first.
+func (g *HNSW[T]) searchLayer(query []T, eps []int, ef int, l int) []Result[T] {
+	visited := make(map[int]struct{}, ef*4)
+	candidates := &minHeap[T]{}
+	found := &MaxHeap[T]{}
+
+	for _, ep := range eps {
+		if _, ok := visited[ep]; ok {
+			continue
+		}
+		visited[ep] = struct{}{}
+		r := Result[T]{Index: ep, Distance: squaredEuclidean(query, g.rows[ep])}
+		heap.Push(candidates, r)
+		heap.Push(found, r)
+	}
+	for found.Len() > ef {
+		heap.Pop(found)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(Result[T])
+		if found.Len() >= ef && c.Distance > found.Peek().(Result[T]).Distance {
+			break
+		}
+
+		for _, n := range g.links[c.Index][l] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := squaredEuclidean(query, g.rows[n])
+			if found.Len() < ef || d < found.Peek().(Result[T]).Distance {
+				heap.Push(candidates, Result[T]{Index: n, Distance: d})
+				found.Process(&n, &ef, &d)
+			}
+		}
+	}
+
+	results := make([]Result[T], found.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(found).(Result[T])
+	}
+	return results
+}
+
+func closest[T float32 | float64](sorted []Result[T], n int) []int {
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	indices := make([]int, len(sorted))
+	for i, r := range sorted {
+		indices[i] = r.Index
+	}
+	return indices
+}
+
+type minHeap[T float32 | float64] []Result[T]
+
+func (h minHeap[T]) Len() int           { return len(h) }
+func (h minHeap[T]) Less(i, j int) bool { return h[i].Distance < h[j].Distance }
+func (h minHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap[T]) Push(x interface{}) {
+	*h = append(*h, x.(Result[T]))
+}
+func (h *minHeap[T]) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,127 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomMatrix(r *rand.Rand, rows, cols int) [][]float32 {
+	data := make([][]float32, rows)
+	for i := range data {
+		data[i] = make([]float32, cols)
+		for j := range data[i] {
+			data[i][j] = r.Float32()
+		}
+	}
+	return data
+}
+
+// recall is the share of the exact L2 neighbors found by got.
+func recall(t *testing.T, data *Tensor[float32], query *Tensor[float32], got Neighbors[float32], k int) float64 {
+	s := &Search[float32]{Data: data, Query: query}
+	want, err := s.L2(k)
+	if err != nil {
+		t.Fatalf("L2 search failed: %v", err)
+	}
+
+	found := make(map[int]bool)
+	for _, i := range got.Indices {
+		found[i] = true
+	}
+	hits := 0
+	for _, i := range want.Indices {
+		if found[i] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(k)
+}
+
+func TestHNSW(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1000, 8)
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	g := &HNSW[float32]{M: 8, EfConstruction: 100, EfSearch: 64, Seed: 1}
+	if err := g.New(dataTensor); err != nil {
+		t.Fatalf("Failed to build HNSW: %v", err)
+	}
+	if g.Len() != len(data) {
+		t.Errorf("Expected %d nodes, got %d", len(data), g.Len())
+	}
+
+	k := 10
+	total := 0.0
+	queries := randomMatrix(r, 50, 8)
+	for _, q := range queries {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(q)
+
+		neighbors, err := g.Search(queryTensor, k)
+		if err != nil {
+			t.Fatalf("HNSW search failed: %v", err)
+		}
+		if len(neighbors.Indices) != k {
+			t.Fatalf("Expected %d neighbors, got %d", k, len(neighbors.Indices))
+		}
+		for i := 1; i < k; i++ {
+			if neighbors.Values[i] < neighbors.Values[i-1] {
+				t.Errorf("Values not sorted: %v", neighbors.Values)
+			}
+		}
+		for i, index := range neighbors.Indices {
+			want := math.Sqrt(float64(squaredEuclidean(q, data[index])))
+			if math.Abs(float64(neighbors.Values[i])-want) > 1e-5 {
+				t.Errorf("Value at %d is not the distance to row %d. Got %f, want %f", i, index, neighbors.Values[i], want)
+			}
+		}
+
+		total += recall(t, dataTensor, queryTensor, neighbors, k)
+	}
+
+	if avg := total / float64(len(queries)); avg < 0.9 {
+		t.Errorf("Expected recall of at least 0.9, got %f", avg)
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		vector := []float32{5, 5, 5, 5, 5, 5, 5, 5}
+		id, err := g.Insert(vector)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if id != len(data) {
+			t.Errorf("Expected id %d, got %d", len(data), id)
+		}
+
+		// the caller may reuse its buffer
+		for j := range vector {
+			vector[j] = 0
+		}
+
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New([]float32{5, 5, 5, 5, 5, 5, 5, 5})
+		neighbors, _ := g.Search(queryTensor, 1)
+		if neighbors.Indices[0] != id || neighbors.Values[0] != 0 {
+			t.Errorf("Expected inserted vector %d at distance 0, got %v", id, neighbors)
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New([]float32{1, 2})
+		if _, err := g.Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for mismatched dimensions, got nil")
+		}
+		if _, err := g.Insert([]float32{1, 2}); err == nil {
+			t.Error("Expected error for mismatched insert, got nil")
+		}
+
+		empty := &HNSW[float32]{}
+		if _, err := empty.Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for empty index, got nil")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package knn
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// Index holds a data tensor together with per-row statistics that are
+// computed once in New and reused by every query.
+type Index[T float32 | float64] struct {
+	Data        *Tensor[T]
+	Multithread bool
+	MaxWorkers  int
+	SIMD        bool
+
+	halfnorm []T
+	norms    []T
+
+	// Mahalanobis whitening, computed on the first query that needs it
+	whitenMu sync.Mutex
+	whitened *Search[T]
+}
+
+func (idx *Index[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+
+	idx.Data = data
+	idx.whitenMu.Lock()
+	idx.whitened = nil
+	idx.whitenMu.Unlock()
+	if _, ok := data.Values.([][]uint64); ok {
+		return nil
+	}
+
+	idx.halfnorm = idx.search(nil).HalfNorm()
+	idx.norms = make([]T, len(idx.halfnorm))
+	for i, hn := range idx.halfnorm {
+		idx.norms[i] = T(math.Sqrt(float64(hn * 2)))
+	}
+
+	return nil
+}
+
+func (idx *Index[T]) Search(query *Tensor[T], k int, metric int) (Neighbors[T], error) {
+	if idx.Data == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+
+	s, err := idx.prepare(query, metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	return s.metric(k, metric)
+}
+
+func (idx *Index[T]) BatchSearch(queries *Tensor[T], k int, metric int) ([]Neighbors[T], error) {
+	if idx.Data == nil {
+		return nil, errors.New("index must be initialized with New")
+	}
+
+	s, err := idx.prepare(queries, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.batch(k, metric)
+}
+
+func (idx *Index[T]) search(query *Tensor[T]) *Search[T] {
+	return &Search[T]{
+		Data:        idx.Data,
+		Query:       query,
+		Multithread: idx.Multithread,
+		MaxWorkers:  idx.MaxWorkers,
+		SIMD:        idx.SIMD,
+		halfnorm:    idx.halfnorm,
+		norms:       idx.norms,
+		normsOf:     [2]*Tensor[T]{idx.Data, nil},
+	}
+}
+
+// prepare returns the search for a query, with the cached whitening when
+// the metric is Mahalanobis.
+func (idx *Index[T]) prepare(query *Tensor[T], metric int) (*Search[T], error) {
+	s := idx.search(query)
+	if metric != Mahalanobis {
+		return s, nil
+	}
+
+	idx.whitenMu.Lock()
+	defer idx.whitenMu.Unlock()
+
+	if idx.whitened == nil {
+		w := idx.search(nil)
+		if err := w.whiten(); err != nil {
+			return nil, err
+		}
+		idx.whitened = w
+	}
+
+	s.whitener = idx.whitened.whitener
+	s.whitened = idx.whitened.whitened
+	s.whitenedHalfnorm = idx.whitened.whitenedHalfnorm
+	s.whitenedOf = idx.whitened.whitenedOf
+
+	return s, nil
+}
@@ -0,0 +1,121 @@
+package knn
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	data := [][]float32{
+		{1.0, 2.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{7.0, 8.0, 9.0},
+		{10.0, 11.0, 12.0},
+	}
+	queries := [][]float32{
+		{3.0, 4.0, 5.0},
+		{9.0, 9.0, 9.0},
+	}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	idx := &Index[float32]{}
+	if err := idx.New(dataTensor); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	expectedHalfNorm := []float32{7, 38.5, 97, 182.5}
+	if !reflect.DeepEqual(idx.halfnorm, expectedHalfNorm) {
+		t.Errorf("Cached half norms mismatch. Got %v, want %v", idx.halfnorm, expectedHalfNorm)
+	}
+
+	for _, metric := range []int{L1, L2, MIPS, Cosine} {
+		for _, query := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(query)
+
+			got, err := idx.Search(queryTensor, 2, metric)
+			if err != nil {
+				t.Fatalf("Index search failed for metric %d: %v", metric, err)
+			}
+
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+			want, err := s.metric(2, metric)
+			if err != nil {
+				t.Fatalf("Search failed for metric %d: %v", metric, err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Metric %d mismatch. Got %v, want %v", metric, got, want)
+			}
+		}
+	}
+
+	t.Run("BatchSearch", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries)
+
+		got, err := idx.BatchSearch(queryTensor, 2, L2)
+		if err != nil {
+			t.Fatalf("Index batch search failed: %v", err)
+		}
+
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		want, _ := s.BatchL2(2)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("BatchSearch mismatch. Got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Mahalanobis", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		data := &Tensor[float32]{}
+		_ = data.New(randomMatrix(r, 50, 4))
+		idx := &Index[float32]{}
+		if err := idx.New(data); err != nil {
+			t.Fatalf("Failed to create index: %v", err)
+		}
+
+		var whitened *Tensor[float32]
+		for _, query := range randomMatrix(r, 3, 4) {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(query)
+
+			got, err := idx.Search(queryTensor, 3, Mahalanobis)
+			if err != nil {
+				t.Fatalf("Index search failed: %v", err)
+			}
+			want, _ := (&Search[float32]{Data: data, Query: queryTensor}).Mahalanobis(3)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Mahalanobis mismatch. Got %v, want %v", got, want)
+			}
+
+			if whitened != nil && idx.whitened.whitened != whitened {
+				t.Error("Whitened data was recomputed between queries")
+			}
+			whitened = idx.whitened.whitened
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+
+		if _, err := idx.Search(queryTensor, 2, -1); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+
+		empty := &Index[float32]{}
+		if _, err := empty.Search(queryTensor, 2, L2); err == nil {
+			t.Error("Expected error for uninitialized index, got nil")
+		}
+
+		vector := &Tensor[float32]{}
+		_ = vector.New(queries[0])
+		if err := empty.New(vector); err == nil {
+			t.Error("Expected error for rank-1 data, got nil")
+		}
+	})
+}
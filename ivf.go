@@ -0,0 +1,155 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// IVF is an inverted file index. Rows are grouped by their nearest k-means
+// centroid and a query only scans the NProbe closest groups.
+type IVF[T float32 | float64] struct {
+	NList      int   // number of inverted lists (default = sqrt(n_rows))
+	NProbe     int   // lists scanned per query (default = 1)
+	Iterations int   // k-means iterations (default = 20)
+	Metric     int   // L1 or L2
+	Seed       int64 // seed for k-means
+
+	centroids *Index[T]
+	lists     []ivfList[T]
+	dim       int
+}
+
+type ivfList[T float32 | float64] struct {
+	ids      []int
+	data     *Tensor[T]
+	halfnorm []T
+}
+
+func (ivf *IVF[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+	if ivf.Metric != L1 && ivf.Metric != L2 {
+		return fmt.Errorf("unsupported metric: %d", ivf.Metric)
+	}
+
+	if !data.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+	if ivf.NList <= 0 {
+		ivf.NList = max(1, int(math.Sqrt(float64(len(rows)))))
+	}
+	if ivf.NProbe <= 0 {
+		ivf.NProbe = 1
+	}
+	if ivf.Iterations <= 0 {
+		ivf.Iterations = 20
+	}
+
+	centroids, assign, err := kmeans(rows, ivf.NList, ivf.Iterations, rand.New(rand.NewSource(ivf.Seed)))
+	if err != nil {
+		return err
+	}
+
+	c := &Tensor[T]{}
+	if err := c.New(centroids); err != nil {
+		return err
+	}
+	ivf.centroids = &Index[T]{}
+	if err := ivf.centroids.New(c); err != nil {
+		return err
+	}
+
+	members := make([][]int, ivf.NList)
+	for i, l := range assign {
+		members[l] = append(members[l], i)
+	}
+
+	ivf.lists = make([]ivfList[T], ivf.NList)
+	for l, ids := range members {
+		if len(ids) == 0 {
+			continue
+		}
+		listRows := make([][]T, len(ids))
+		for i, id := range ids {
+			listRows[i] = rows[id]
+		}
+
+		list := &Tensor[T]{}
+		if err := list.New(listRows); err != nil {
+			return err
+		}
+		ivf.lists[l] = ivfList[T]{
+			ids:      ids,
+			data:     list,
+			halfnorm: (&Search[T]{Data: list}).HalfNorm(),
+		}
+	}
+	ivf.dim = data.Shape[1]
+
+	return nil
+}
+
+// Search scans the NProbe closest lists and returns up to k rows, nearest
+// first. Values are L1 or Euclidean distances. Fewer than k rows are
+// returned when the probed lists hold fewer rows.
+func (ivf *IVF[T]) Search(query *Tensor[T], k int) (Neighbors[T], error) {
+	if ivf.centroids == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+	if query == nil || query.Rank != 1 {
+		return Neighbors[T]{}, errors.New("query must be a vector")
+	}
+	if query.Shape[0] != ivf.dim {
+		return Neighbors[T]{}, errors.New("data and query dimensions do not match")
+	}
+	if k <= 0 {
+		return Neighbors[T]{}, errors.New("k must be greater than 0")
+	}
+
+	probes, err := ivf.centroids.Search(query, min(ivf.NProbe, ivf.NList), ivf.Metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	h := &MaxHeap[T]{}
+	heap.Init(h)
+
+	for _, l := range probes.Indices {
+		list := ivf.lists[l]
+		if list.data == nil {
+			continue
+		}
+
+		s := &Search[T]{Data: list.data, Query: query}
+		if ivf.Metric == L1 {
+			for j := range list.ids {
+				distance := s.Manhattan(&j)
+				h.Process(&list.ids[j], &k, &distance)
+			}
+			continue
+		}
+
+		for j, dot := range s.Einsum() {
+			distance := list.halfnorm[j] - dot
+			h.Process(&list.ids[j], &k, &distance)
+		}
+	}
+
+	n := h.Len()
+	nn, err := (&Search[T]{}).ret(&n, h)
+	if err != nil || ivf.Metric == L1 {
+		return nn, err
+	}
+
+	qnorm := float64(norm(query.Values.([]T)))
+	for i, v := range nn.Values {
+		nn.Values[i] = T(math.Sqrt(math.Max(0, 2*float64(v)+qnorm*qnorm)))
+	}
+
+	return nn, nil
+}
@@ -0,0 +1,90 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestIVF(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1000, 8)
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	queries := randomMatrix(r, 20, 8)
+
+	t.Run("All lists probed is exact", func(t *testing.T) {
+		for _, metric := range []int{L1, L2} {
+			ivf := &IVF[float32]{NList: 16, NProbe: 16, Metric: metric, Seed: 1}
+			if err := ivf.New(dataTensor); err != nil {
+				t.Fatalf("Failed to build IVF: %v", err)
+			}
+
+			for _, q := range queries {
+				queryTensor := &Tensor[float32]{}
+				_ = queryTensor.New(q)
+
+				got, err := ivf.Search(queryTensor, 5)
+				if err != nil {
+					t.Fatalf("IVF search failed: %v", err)
+				}
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+				want, _ := s.metric(5, metric)
+				if !reflect.DeepEqual(got.Indices, want.Indices) {
+					t.Errorf("Metric %d indices mismatch. Got %v, want %v", metric, got.Indices, want.Indices)
+				}
+
+				for i, index := range got.Indices {
+					expected := float64(s.Manhattan(&index))
+					if metric == L2 {
+						expected = math.Sqrt(float64(squaredEuclidean(q, data[index])))
+					}
+					if math.Abs(float64(got.Values[i])-expected) > 1e-4 {
+						t.Errorf("Metric %d value at %d mismatch. Got %f, want %f", metric, i, got.Values[i], expected)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Recall", func(t *testing.T) {
+		ivf := &IVF[float32]{NList: 16, NProbe: 4, Metric: L2, Seed: 1}
+		if err := ivf.New(dataTensor); err != nil {
+			t.Fatalf("Failed to build IVF: %v", err)
+		}
+
+		total := 0.0
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+
+			got, err := ivf.Search(queryTensor, 10)
+			if err != nil {
+				t.Fatalf("IVF search failed: %v", err)
+			}
+			total += recall(t, dataTensor, queryTensor, got, 10)
+		}
+
+		if avg := total / float64(len(queries)); avg < 0.6 {
+			t.Errorf("Expected recall of at least 0.6, got %f", avg)
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		ivf := &IVF[float32]{Metric: MIPS}
+		if err := ivf.New(dataTensor); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+		empty := &IVF[float32]{}
+		if _, err := empty.Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for untrained index, got nil")
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// KDTree is an exact L1/L2 index for low-dimensional data. Each node
+// splits its rows at the median of the dimension with the largest spread,
+// and subtrees that can't beat the current k-th distance are skipped.
+type KDTree[T float32 | float64] struct {
+	LeafSize int // rows per leaf (default = 16)
+
+	data  *Tensor[T]
+	rows  [][]T
+	ids   []int
+	nodes []kdNode[T]
+}
+
+// kdNode holds ids[lo:hi]. Left rows are <= split and right rows are
+// >= split in dimension dim. Leaves have left == -1.
+type kdNode[T float32 | float64] struct {
+	dim         int
+	split       T
+	left, right int
+	lo, hi      int
+}
+
+func (kd *KDTree[T]) New(data *Tensor[T]) error {
+	rows, ids, err := treeRows(data)
+	if err != nil {
+		return err
+	}
+	if kd.LeafSize <= 0 {
+		kd.LeafSize = 16
+	}
+
+	kd.data = data
+	kd.rows = rows
+	kd.ids = ids
+	kd.nodes = nil
+	kd.build(0, len(ids))
+
+	return nil
+}
+
+func (kd *KDTree[T]) build(lo, hi int) int {
+	id := len(kd.nodes)
+	kd.nodes = append(kd.nodes, kdNode[T]{left: -1, right: -1, lo: lo, hi: hi})
+	if hi-lo <= kd.LeafSize {
+		return id
+	}
+
+	dim := widest(kd.rows, kd.ids[lo:hi])
+	mid := (lo + hi) / 2
+	kd.nodes[id].dim = dim
+	kd.nodes[id].split = kd.rows[kd.ids[mid]][dim]
+
+	left := kd.build(lo, mid)
+	right := kd.build(mid, hi)
+	kd.nodes[id].left, kd.nodes[id].right = left, right
+
+	return id
+}
+
+// Search returns the k nearest rows. Values are L1 distances for L1 and
+// Euclidean distances for L2.
+func (kd *KDTree[T]) Search(query *Tensor[T], k int, metric int) (Neighbors[T], error) {
+	if kd.data == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+
+	t, err := newTreeSearch(kd.data, query, metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+	if err := t.s.checker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	t.k = k
+	kd.search(t, 0)
+
+	return t.neighbors(0)
+}
+
+// Range returns every row within radius of the query, nearest first. An
+// optional int caps the number of results.
+func (kd *KDTree[T]) Range(query *Tensor[T], radius T, metric int, opts ...interface{}) (Neighbors[T], error) {
+	if kd.data == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+
+	t, err := newTreeSearch(kd.data, query, metric)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+	if err := t.s.rangeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+	limit, err := rangeLimit("Range", opts)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	t.inRange(radius)
+	kd.search(t, 0)
+
+	return t.neighbors(limit)
+}
+
+func (kd *KDTree[T]) search(t *treeSearch[T], id int) {
+	node := kd.nodes[id]
+	if node.left < 0 {
+		t.scan(kd.rows, kd.ids[node.lo:node.hi])
+		return
+	}
+
+	gap := t.query[node.dim] - node.split
+	near, far := node.left, node.right
+	if gap > 0 {
+		near, far = far, near
+	}
+
+	kd.search(t, near)
+	if t.lower(Abs(gap)) <= t.bound() {
+		kd.search(t, far)
+	}
+}
+
+// treeRows validates the data of a tree index and returns its rows with
+// the identity permutation.
+func treeRows[T float32 | float64](data *Tensor[T]) ([][]T, []int, error) {
+	if data == nil || data.Rank != 2 {
+		return nil, nil, errors.New("data must be a matrix")
+	}
+	if !data.dense() {
+		return nil, nil, errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+
+	ids := make([]int, len(rows))
+	for i := range ids {
+		ids[i] = i
+	}
+
+	return rows, ids, nil
+}
+
+// widest sorts ids by the dimension with the largest spread and returns it.
+func widest[T float32 | float64](rows [][]T, ids []int) int {
+	dim, spread := 0, T(-1)
+	for j := range rows[ids[0]] {
+		lo, hi := rows[ids[0]][j], rows[ids[0]][j]
+		for _, i := range ids {
+			lo = minOf(lo, rows[i][j])
+			hi = maxOf(hi, rows[i][j])
+		}
+		if hi-lo > spread {
+			dim, spread = j, hi-lo
+		}
+	}
+
+	sort.Slice(ids, func(a, b int) bool { return rows[ids[a]][dim] < rows[ids[b]][dim] })
+
+	return dim
+}
+
+// treeSearch collects the results of a tree traversal, either the k
+// nearest rows in a MaxHeap or every row within radius. L2 distances are
+// kept squared until the end.
+type treeSearch[T float32 | float64] struct {
+	s      *Search[T]
+	query  []T
+	metric int
+	k      int
+	h      *MaxHeap[T]
+	radius T
+	r      *Results[T]
+}
+
+func newTreeSearch[T float32 | float64](data, query *Tensor[T], metric int) (*treeSearch[T], error) {
+	if metric != L1 && metric != L2 {
+		return nil, fmt.Errorf("unsupported metric: %d", metric)
+	}
+
+	t := &treeSearch[T]{
+		s:      &Search[T]{Data: data, Query: query},
+		metric: metric,
+		h:      &MaxHeap[T]{},
+	}
+	if query != nil {
+		t.query, _ = query.Values.([]T)
+	}
+	heap.Init(t.h)
+
+	return t, nil
+}
+
+func (t *treeSearch[T]) inRange(radius T) {
+	t.r = &Results[T]{}
+	t.radius = radius
+	// a negative radius stays negative so no row matches
+	if t.metric == L2 && radius > 0 {
+		t.radius = radius * radius
+	}
+}
+
+func (t *treeSearch[T]) distance(row []T) T {
+	if t.metric == L1 {
+		return manhattanUnrolled(t.query, row)
+	}
+	return squaredEuclidean(t.query, row)
+}
+
+// lower turns a lower bound on the distance to a subtree into the units
+// of distance.
+func (t *treeSearch[T]) lower(gap T) T {
+	if t.metric == L2 {
+		return gap * gap
+	}
+	return gap
+}
+
+// bound is the distance a row must not exceed to be kept.
+func (t *treeSearch[T]) bound() T {
+	if t.r != nil {
+		return t.radius
+	}
+	if t.h.Len() < t.k {
+		return T(math.Inf(1))
+	}
+	return t.h.Peek().(Result[T]).Distance
+}
+
+func (t *treeSearch[T]) scan(rows [][]T, ids []int) {
+	for _, i := range ids {
+		if !t.s.keep(i) {
+			continue
+		}
+		distance := t.distance(rows[i])
+		if t.r == nil {
+			t.h.Process(&i, &t.k, &distance)
+		} else if distance <= t.radius {
+			t.r.Process(&i, &distance)
+		}
+	}
+}
+
+// neighbors returns the results, nearest first, capped at limit for range
+// searches when limit > 0.
+func (t *treeSearch[T]) neighbors(limit int) (Neighbors[T], error) {
+	var nn Neighbors[T]
+	if t.r != nil {
+		nn = t.r.Neighbors(limit, false)
+	} else {
+		var err error
+		if nn, err = t.s.ret(&t.k, t.h); err != nil {
+			return nn, err
+		}
+	}
+
+	if t.metric == L2 {
+		for i, v := range nn.Values {
+			nn.Values[i] = T(math.Sqrt(float64(v)))
+		}
+	}
+
+	return nn, nil
+}
@@ -0,0 +1,126 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+type treeIndex interface {
+	New(data *Tensor[float32]) error
+	Search(query *Tensor[float32], k int, metric int) (Neighbors[float32], error)
+	Range(query *Tensor[float32], radius float32, metric int, opts ...interface{}) (Neighbors[float32], error)
+}
+
+// testTree checks a tree index against brute-force search.
+func testTree(t *testing.T, tree treeIndex) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 2000, 3)
+	data = append(data, data[0], data[0]) // duplicate rows
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	if err := tree.New(dataTensor); err != nil {
+		t.Fatalf("Failed to build tree: %v", err)
+	}
+
+	queries := randomMatrix(r, 20, 3)
+
+	t.Run("Search", func(t *testing.T) {
+		for _, metric := range []int{L1, L2} {
+			for _, q := range queries {
+				queryTensor := &Tensor[float32]{}
+				_ = queryTensor.New(q)
+
+				got, err := tree.Search(queryTensor, 10, metric)
+				if err != nil {
+					t.Fatalf("Tree search failed: %v", err)
+				}
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+				want, _ := s.metric(10, metric)
+				if !reflect.DeepEqual(got.Indices, want.Indices) {
+					t.Errorf("Metric %d indices mismatch. Got %v, want %v", metric, got.Indices, want.Indices)
+				}
+
+				for i, index := range got.Indices {
+					expected := float64(s.Manhattan(&index))
+					if metric == L2 {
+						expected = math.Sqrt(float64(squaredEuclidean(q, data[index])))
+					}
+					if math.Abs(float64(got.Values[i])-expected) > 1e-5 {
+						t.Errorf("Metric %d value at %d mismatch. Got %f, want %f", metric, i, got.Values[i], expected)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+
+			got, err := tree.Range(queryTensor, 0.2, L1)
+			if err != nil {
+				t.Fatalf("Tree range search failed: %v", err)
+			}
+			want, _ := s.RangeL1(0.2)
+			if !reflect.DeepEqual(got.Indices, want.Indices) {
+				t.Errorf("RangeL1 indices mismatch. Got %v, want %v", got.Indices, want.Indices)
+			}
+
+			got, _ = tree.Range(queryTensor, 0.15, L2, 5)
+			want, _ = s.RangeL2(0.15, 5)
+			if !reflect.DeepEqual(got.Indices, want.Indices) {
+				t.Errorf("RangeL2 indices mismatch. Got %v, want %v", got.Indices, want.Indices)
+			}
+
+			for _, metric := range []int{L1, L2} {
+				got, err = tree.Range(queryTensor, -1, metric)
+				if err != nil || len(got.Indices) != 0 {
+					t.Errorf("Negative radius returned %v, %v", got.Indices, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+		short := &Tensor[float32]{}
+		_ = short.New([]float32{1, 2})
+
+		if _, err := tree.Search(queryTensor, 1, MIPS); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+		if _, err := tree.Search(queryTensor, 0, L2); err == nil {
+			t.Error("Expected error for invalid k, got nil")
+		}
+		if _, err := tree.Search(queryTensor, len(data)+1, L2); err == nil {
+			t.Error("Expected error for k larger than the data, got nil")
+		}
+		if _, err := tree.Search(short, 1, L2); err == nil {
+			t.Error("Expected error for dimension mismatch, got nil")
+		}
+		if _, err := tree.Search(nil, 1, L2); err == nil {
+			t.Error("Expected error for nil query, got nil")
+		}
+		if _, err := tree.Range(queryTensor, 1, L1, "5"); err == nil {
+			t.Error("Expected error for invalid limit, got nil")
+		}
+		if err := tree.New(queryTensor); err == nil {
+			t.Error("Expected error for vector data, got nil")
+		}
+	})
+}
+
+func TestKDTree(t *testing.T) {
+	testTree(t, &KDTree[float32]{LeafSize: 8})
+
+	if _, err := (&KDTree[float32]{}).Search(nil, 1, L2); err == nil {
+		t.Error("Expected error for empty index, got nil")
+	}
+}
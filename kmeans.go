@@ -0,0 +1,125 @@
+package knn
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// kmeans trains k centroids with k-means++ seeding and Lloyd iterations,
+// returning the centroids and the centroid of every row.
+func kmeans[T float32 | float64](data [][]T, k int, iterations int, rng *rand.Rand) ([][]T, []int, error) {
+	if k <= 0 || k > len(data) {
+		return nil, nil, errors.New("number of centroids must be greater than 0 and less than the length of the data")
+	}
+
+	dim := len(data[0])
+	centroids := seed(data, k, rng)
+	assign := make([]int, len(data))
+
+	for it := 0; it < iterations; it++ {
+		changed, err := assignRows(data, centroids, assign)
+		if err != nil {
+			return nil, nil, err
+		}
+		if it > 0 && !changed {
+			break
+		}
+
+		counts := make([]int, k)
+		sums := make([][]float64, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, row := range data {
+			counts[assign[i]]++
+			for j, x := range row {
+				sums[assign[i]][j] += float64(x)
+			}
+		}
+
+		for c := range centroids {
+			if counts[c] == 0 {
+				// empty cluster, restart it on a random row
+				copy(centroids[c], data[rng.Intn(len(data))])
+				continue
+			}
+			for j := range centroids[c] {
+				centroids[c][j] = T(sums[c][j] / float64(counts[c]))
+			}
+		}
+	}
+	if _, err := assignRows(data, centroids, assign); err != nil {
+		return nil, nil, err
+	}
+
+	return centroids, assign, nil
+}
+
+// seed picks k-means++ initial centroids.
+func seed[T float32 | float64](data [][]T, k int, rng *rand.Rand) [][]T {
+	centroids := make([][]T, 0, k)
+	first := data[rng.Intn(len(data))]
+	centroids = append(centroids, append([]T{}, first...))
+
+	distances := make([]float64, len(data))
+	for i, row := range data {
+		distances[i] = float64(squaredEuclidean(row, first))
+	}
+
+	for len(centroids) < k {
+		total := 0.0
+		for _, d := range distances {
+			total += d
+		}
+
+		next := rng.Intn(len(data))
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range distances {
+				target -= d
+				if target <= 0 {
+					next = i
+					break
+				}
+			}
+		}
+
+		centroid := append([]T{}, data[next]...)
+		centroids = append(centroids, centroid)
+		for i, row := range data {
+			if d := float64(squaredEuclidean(row, centroid)); d < distances[i] {
+				distances[i] = d
+			}
+		}
+	}
+
+	return centroids
+}
+
+// assignRows moves every row to its nearest centroid and reports whether
+// any assignment changed.
+func assignRows[T float32 | float64](data [][]T, centroids [][]T, assign []int) (bool, error) {
+	c := &Tensor[T]{}
+	if err := c.New(centroids); err != nil {
+		return false, err
+	}
+	idx := &Index[T]{}
+	if err := idx.New(c); err != nil {
+		return false, err
+	}
+
+	changed := false
+	for i, row := range data {
+		q := &Tensor[T]{Values: row, Shape: [2]int{len(row)}, Type: c.Type, Rank: 1}
+		nn, err := idx.Search(q, 1, L2)
+		if err != nil {
+			return false, err
+		}
+		if assign[i] != nn.Indices[0] {
+			assign[i] = nn.Indices[0]
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,45 @@
+package knn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestKMeans(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	centers := [][]float32{{0, 0}, {10, 10}, {-10, 10}}
+
+	var data [][]float32
+	for i := 0; i < 300; i++ {
+		c := centers[i%len(centers)]
+		data = append(data, []float32{c[0] + r.Float32() - 0.5, c[1] + r.Float32() - 0.5})
+	}
+
+	centroids, assign, err := kmeans(data, 3, 20, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("kmeans failed: %v", err)
+	}
+
+	// rows generated from the same center share a centroid
+	for i := range data {
+		if assign[i] != assign[i%len(centers)] {
+			t.Fatalf("Row %d assigned to %d, expected %d", i, assign[i], assign[i%len(centers)])
+		}
+	}
+
+	for i, c := range centers {
+		if d := squaredEuclidean(c, centroids[assign[i]]); d > 0.1 {
+			t.Errorf("Centroid %v too far from center %v", centroids[assign[i]], c)
+		}
+	}
+
+	if _, _, err := kmeans(data, 0, 20, r); err == nil {
+		t.Error("Expected error for k=0, got nil")
+	}
+	if _, _, err := kmeans(data[:2], 3, 20, r); err == nil {
+		t.Error("Expected error for k greater than the data, got nil")
+	}
+	if _, err := assignRows([][]float32{{1, 2, 3}}, [][]float32{{1, 2}}, []int{0}); err == nil {
+		t.Error("Expected error for mismatched dimensions, got nil")
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"container/heap"
 	"errors"
 	"fmt"
-	"runtime"
+	"math"
 	"sync"
 	"unsafe"
 )
@@ -15,6 +15,19 @@ type Search[T float32 | float64] struct {
 	Multithread bool
 	MaxWorkers  int
 	SIMD        bool
+	Normalized  bool                 // data rows have unit length, see Tensor.Normalize
+	Weights     *Tensor[T]           // per-dimension weights for L1, L2, MIPS and Cosine, other metrics return an error
+	InvCov      *Tensor[T]           // inverse covariance for Mahalanobis, estimated from Data if nil
+	Filter      func(index int) bool // rows returning false are skipped, see Bitset
+
+	halfnorm []T
+	norms    []T
+	normsOf  [2]*Tensor[T]
+
+	whitener         whitener[T]
+	whitened         *Tensor[T]
+	whitenedHalfnorm []T
+	whitenedOf       [2]*Tensor[T]
 }
 
 type Neighbors[T any] struct {
@@ -26,6 +39,13 @@ const (
 	L1 = iota
 	L2
 	MIPS
+	Cosine
+	Chebyshev
+	Hamming
+	Jaccard
+	Canberra
+	BrayCurtis
+	Mahalanobis
 )
 
 func (s *Search[T]) L1(k int) (Neighbors[T], error) {
@@ -33,53 +53,61 @@ func (s *Search[T]) L1(k int) (Neighbors[T], error) {
 		return Neighbors[T]{}, err
 	}
 
-	h := &MaxHeap[T]{}
-	heap.Init(h)
+	return s.scan(k, func(i int) T {
+		return s.Manhattan(&i)
+	})
+}
 
-	if s.Multithread {
-		if s.MaxWorkers == 0 {
-			s.MaxWorkers = runtime.NumCPU()
-		}
-		n_tasks := len(s.Data.Values.([][]T))
-
-		var wg sync.WaitGroup
-		results := make(chan struct {
-			index    int
-			distance T
-		}, n_tasks)
-
-		worker := func(s *Search[T], i int) {
-			defer wg.Done()
-			distance := s.Manhattan(&i)
-			results <- struct {
-				index    int
-				distance T
-			}{i, distance}
-		}
+func (s *Search[T]) Chebyshev(k int) (Neighbors[T], error) {
+	return s.kernel(k, chebyshev[T])
+}
 
-		for i := 0; i < n_tasks; i++ {
-			wg.Add(1)
-			go worker(s, i)
-		}
+func (s *Search[T]) Minkowski(k int, p T) (Neighbors[T], error) {
+	if p < 1 {
+		return Neighbors[T]{}, errors.New("p must be greater than or equal to 1")
+	}
+	return s.kernel(k, func(query, data []T) T {
+		return minkowski(query, data, p)
+	})
+}
 
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+// Hamming counts differing elements, or differing bits when Data and Query
+// are binary tensors (see NewBinary and Binarize).
+func (s *Search[T]) Hamming(k int) (Neighbors[T], error) {
+	if s.binary() {
+		return s.hammingBits(k)
+	}
+	return s.kernel(k, hamming[T])
+}
 
-		for result := range results {
-			h.Process(&result.index, &k, &result.distance)
-		}
+func (s *Search[T]) Jaccard(k int) (Neighbors[T], error) {
+	return s.kernel(k, jaccard[T])
+}
 
-		return s.ret(&k, h)
+func (s *Search[T]) Canberra(k int) (Neighbors[T], error) {
+	return s.kernel(k, canberra[T])
+}
+
+func (s *Search[T]) BrayCurtis(k int) (Neighbors[T], error) {
+	return s.kernel(k, braycurtis[T])
+}
+
+func (s *Search[T]) kernel(k int, distance func(query, data []T) T) (Neighbors[T], error) {
+	if err := s.checker(k); err != nil {
+		return Neighbors[T]{}, err
 	}
 
-	for i := 0; i < len(s.Data.Values.([][]T)); i++ {
-		distance := s.Manhattan(&i)
-		h.Process(&i, &k, &distance)
+	query := s.Query.Values.([]T)
+	if !s.Data.dense() {
+		return Neighbors[T]{}, errors.New("quantized data only supports L1, L2, MIPS and Cosine")
+	}
+	if s.Weights != nil {
+		return Neighbors[T]{}, errors.New("weights only support L1, L2, MIPS and Cosine")
 	}
 
-	return s.ret(&k, h)
+	return s.scan(k, func(i int) T {
+		return distance(query, s.Data.Row(i))
+	})
 }
 
 func (s *Search[T]) L2(k int) (Neighbors[T], error) {
@@ -87,39 +115,289 @@ func (s *Search[T]) L2(k int) (Neighbors[T], error) {
 		return Neighbors[T]{}, err
 	}
 
-	h := &MaxHeap[T]{}
-	heap.Init(h)
+	halfnorm := s.halfnorm
+	if halfnorm == nil {
+		halfnorm = s.HalfNorm()
+	}
+	rowDot := s.rowDot(s.weighted(s.Query.Values.([]T)))
+
+	return s.scan(k, func(i int) T {
+		return halfnorm[i] - rowDot(i)
+	})
+}
 
-	dots := s.Einsum()
-	halfnorm := s.HalfNorm()
+func (s *Search[T]) MIPS(k int, opts ...interface{}) (Neighbors[T], error) {
+	if err := s.checker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
 
-	for i := range dots {
-		distance := halfnorm[i] - dots[i]
-		h.Process(&i, &k, &distance)
+	bs, err := s.binSize(s.Query.Shape[0], opts)
+	if err != nil {
+		return Neighbors[T]{}, err
 	}
 
-	return s.ret(&k, h)
+	scores := s.Einsum()
+	if scores == nil {
+		return Neighbors[T]{}, errors.New("unknown error while calculating scores")
+	}
+
+	return s.mips(scores, k, bs)
 }
 
-func (s *Search[T]) MIPS(k int, opts ...interface{}) (Neighbors[T], error) {
+func (s *Search[T]) Cosine(k int) (Neighbors[T], error) {
 	if err := s.checker(k); err != nil {
 		return Neighbors[T]{}, err
 	}
 
-	if s.Multithread {
-		Log("MIPS does not support multithreading for now", Warning)
+	norms := s.rowNorms()
+	query := s.Query.Values.([]T)
+
+	return s.cosine(s.rowDot(s.weighted(query)), norms, s.queryNorm(query), k)
+}
+
+func (s *Search[T]) BatchL1(k int) ([]Neighbors[T], error) {
+	if err := s.batchChecker(k); err != nil {
+		return nil, err
+	}
+
+	results := make([]Neighbors[T], s.Query.Shape[0])
+	for q := range results {
+		nn, err := s.single(q).L1(k)
+		if err != nil {
+			return nil, err
+		}
+		results[q] = nn
+	}
+
+	return results, nil
+}
+
+func (s *Search[T]) BatchL2(k int) ([]Neighbors[T], error) {
+	if err := s.batchChecker(k); err != nil {
+		return nil, err
+	}
+
+	halfnorm := s.halfnorm
+	if halfnorm == nil {
+		halfnorm = s.HalfNorm()
+	}
+
+	return s.batchScan(k, func(q, i int, dot T) T {
+		return halfnorm[i] - dot
+	}), nil
+}
+
+func (s *Search[T]) BatchMIPS(k int, opts ...interface{}) ([]Neighbors[T], error) {
+	if err := s.batchChecker(k); err != nil {
+		return nil, err
+	}
+
+	bs, err := s.binSize(s.Query.Shape[1], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// too few non-empty bins to hold k results, fall back to every row
+	if s.bins(bs) < k {
+		bs = 1
+	}
+
+	// worker ranges hold whole bins, so every worker picks the winner of
+	// its bins and keeps the k best winners per query
+	results := make([]Results[T], s.Query.Shape[0])
+	s.batchDots(bs, func(qlo, qhi int) (func(q, i int, dot T), func()) {
+		heaps := make([]MaxHeap[T], qhi-qlo)
+		best := make([]Result[T], qhi-qlo)
+		for q := range best {
+			best[q].Index = -1
+		}
+		flush := func(q int) {
+			if b := best[q]; b.Index >= 0 {
+				heaps[q].Process(&b.Index, &k, &b.Distance)
+				best[q].Index = -1
+			}
+		}
+
+		visit := func(q, i int, dot T) {
+			b := &best[q-qlo]
+			if b.Index >= 0 && b.Index/bs != i/bs {
+				flush(q - qlo)
+			}
+			if b.Index < 0 || -dot < b.Distance {
+				*b = Result[T]{Index: i, Distance: -dot}
+			}
+		}
+		done := func() {
+			for q := range heaps {
+				flush(q)
+				results[qlo+q] = append(results[qlo+q], heaps[q].results...)
+			}
+		}
+		return visit, done
+	})
+
+	nns := make([]Neighbors[T], len(results))
+	for q, r := range results {
+		nns[q], _ = negate(r.Neighbors(k, false), nil)
+	}
+
+	return nns, nil
+}
+
+func (s *Search[T]) metric(k int, metric int) (Neighbors[T], error) {
+	switch metric {
+	case L1:
+		return s.L1(k)
+	case L2:
+		return s.L2(k)
+	case MIPS:
+		return s.MIPS(k)
+	case Cosine:
+		return s.Cosine(k)
+	case Chebyshev:
+		return s.Chebyshev(k)
+	case Hamming:
+		return s.Hamming(k)
+	case Jaccard:
+		return s.Jaccard(k)
+	case Canberra:
+		return s.Canberra(k)
+	case BrayCurtis:
+		return s.BrayCurtis(k)
+	case Mahalanobis:
+		return s.Mahalanobis(k)
+	default:
+		return Neighbors[T]{}, fmt.Errorf("unsupported metric: %d", metric)
+	}
+}
+
+func (s *Search[T]) batch(k int, metric int) ([]Neighbors[T], error) {
+	if s.Data != nil && metric == Hamming {
+		if _, ok := s.Data.Values.([][]uint64); ok {
+			return s.batchHamming(k)
+		}
+	}
+
+	switch metric {
+	case L1:
+		return s.BatchL1(k)
+	case L2:
+		return s.BatchL2(k)
+	case MIPS:
+		return s.BatchMIPS(k)
+	case Cosine:
+		return s.BatchCosine(k)
+	}
+
+	if err := s.batchChecker(k); err != nil {
+		return nil, err
+	}
+
+	results := make([]Neighbors[T], s.Query.Shape[0])
+	for q := range results {
+		nn, err := s.single(q).metric(k, metric)
+		if err != nil {
+			return nil, err
+		}
+		results[q] = nn
+	}
+
+	return results, nil
+}
+
+func (s *Search[T]) BatchCosine(k int) ([]Neighbors[T], error) {
+	if err := s.batchChecker(k); err != nil {
+		return nil, err
+	}
+
+	norms := s.rowNorms()
+	queries := s.Query.rows()
+	qnorms := make([]T, len(queries))
+	for q, query := range queries {
+		qnorms[q] = s.queryNorm(query)
+	}
+
+	results := s.batchScan(k, func(q, i int, dot T) T {
+		return cosineScore(dot, norms, i, qnorms[q])
+	})
+	for q := range results {
+		results[q], _ = negate(results[q], nil)
+	}
+
+	return results, nil
+}
+
+// cosine ranks by similarity, highest first. Scores are negated so the
+// scan keeps the k largest.
+func (s *Search[T]) cosine(dot func(i int) T, norms []T, qnorm T, k int) (Neighbors[T], error) {
+	return negate(s.scan(k, func(i int) T {
+		return cosineScore(dot(i), norms, i, qnorm)
+	}))
+}
+
+// cosineScore is the negated similarity of row i, 0 for zero norms.
+func cosineScore[T float32 | float64](dot T, norms []T, i int, qnorm T) T {
+	switch {
+	case qnorm == 0:
+		return 0
+	case norms == nil:
+		return -dot / qnorm
+	case norms[i] != 0:
+		return -dot / (norms[i] * qnorm)
+	}
+	return 0
+}
+
+func negate[T float32 | float64](nn Neighbors[T], err error) (Neighbors[T], error) {
+	for i := range nn.Values {
+		nn.Values[i] = -nn.Values[i]
+	}
+	return nn, err
+}
+
+// rowNorms returns the cached L2 norm of every data row, or nil when the
+// data is already normalized. Weighted norms of unit rows are not 1, so
+// Normalized is ignored when Weights is set.
+func (s *Search[T]) rowNorms() []T {
+	if s.Normalized && s.Weights == nil {
+		return nil
 	}
+	if s.norms == nil || s.normsOf != [2]*Tensor[T]{s.Data, s.Weights} {
+		s.norms = s.Norm()
+		s.normsOf = [2]*Tensor[T]{s.Data, s.Weights}
+	}
+	return s.norms
+}
+
+// keepCaches copies the row norms and whitening built by a copy of s.
+func (s *Search[T]) keepCaches(c *Search[T]) {
+	if c.Data != s.Data {
+		return
+	}
+	if c.normsOf[0] == s.Data && c.normsOf[1] == s.Weights {
+		s.norms, s.normsOf = c.norms, c.normsOf
+	}
+	if c.whitenedOf[0] == s.Data && c.whitenedOf[1] == s.InvCov {
+		s.whitener, s.whitened = c.whitener, c.whitened
+		s.whitenedHalfnorm, s.whitenedOf = c.whitenedHalfnorm, c.whitenedOf
+	}
+}
+
+func (s *Search[T]) queryNorm(query []T) T {
+	return T(math.Sqrt(float64(s.squaredNorm(query, len(query)))))
+}
 
+func (s *Search[T]) binSize(dim int, opts []interface{}) (int, error) {
 	bs := s.EstimateBinSize()
 	if len(opts) > 0 {
 		var ok bool
 		bs, ok = opts[0].(int)
 		if !ok {
-			return Neighbors[T]{}, errors.New("invalid options for MIPS")
+			return 0, errors.New("invalid options for MIPS")
 		}
 	}
-	if bs <= 0 || bs > 64 || bs > s.Query.Shape[0] {
-		return Neighbors[T]{}, errors.New("invalid bin_size")
+	if bs <= 0 || bs > 64 || bs > dim {
+		return 0, errors.New("invalid bin_size")
 	}
 
 	// Warnings: just an observation, magic number ig
@@ -132,76 +410,158 @@ func (s *Search[T]) MIPS(k int, opts ...interface{}) (Neighbors[T], error) {
 		Log("bin_size is not a power of 2. This may lead to unexpected results.", Warning)
 	}
 
-	scores := s.Einsum()
-	if scores == nil {
-		return Neighbors[T]{}, errors.New("unknown error while calculating scores")
-	}
+	return bs, nil
+}
+
+// mips is the approximate top-k of https://arxiv.org/pdf/2206.14286: the
+// best score in each bin of bs consecutive rows is kept, and the k largest
+// of those L winners are selected exactly. bs = 1 is an exact search.
+func (s *Search[T]) mips(scores []T, k int, bs int) (Neighbors[T], error) {
 	if k > len(scores) {
 		return Neighbors[T]{}, errors.New("k must be less than the length of the scores vector")
 	}
 
-	// gets messy, see https://arxiv.org/pdf/2206.14286
+	N := len(scores)
+	L := (N + bs - 1) / bs
+	A := s.binWinners(scores, bs)
+
+	winners := make([]int, 0, L)
+	for _, j := range A {
+		if j >= 0 {
+			winners = append(winners, j)
+		}
+	}
+
+	// too few non-empty bins to hold k results, fall back to every row
+	if len(winners) < k {
+		winners = winners[:0]
+		for j := range scores {
+			if s.keep(j) {
+				winners = append(winners, j)
+			}
+		}
+	}
+
+	h := &MaxHeap[T]{}
+	heap.Init(h)
+	for _, j := range winners {
+		distance := -scores[j]
+		h.Process(&j, &k, &distance)
+	}
 
+	return negate(s.ret(&k, h))
+}
+
+// binWinners returns the index of the best kept row of each bin, or -1 for
+// empty bins. Every worker reduces a contiguous chunk of rows and the
+// partial maxima are merged, keeping the lowest index on ties.
+func (s *Search[T]) binWinners(scores []T, bs int) []int {
 	N := len(scores)
-	L := N / bs
+	winners := make([]int, (N+bs-1)/bs)
+	best := make([]T, len(winners))
+	for l := range winners {
+		winners[l] = -1
+	}
+
+	var mu sync.Mutex
+	s.chunks(N, func(lo, hi int) {
+		V, A := s.reduceBins(scores, lo, hi, bs)
+
+		mu.Lock()
+		defer mu.Unlock()
+		first := lo / bs
+		for l, j := range A {
+			g := first + l
+			if j < 0 {
+				continue
+			}
+			if winners[g] < 0 || V[l] > best[g] || (V[l] == best[g] && j < winners[g]) {
+				best[g] = V[l]
+				winners[g] = j
+			}
+		}
+	})
 
-	if N%bs != 0 {
-		L++
+	return winners
+}
+
+// reduceBins keeps the best score of rows lo to hi in each bin they touch,
+// starting with bin lo/bs.
+func (s *Search[T]) reduceBins(scores []T, lo, hi, bs int) ([]T, []int) {
+	if lo >= hi {
+		return nil, nil
 	}
 
+	first := lo / bs
+	L := (hi-1)/bs - first + 1
 	V := make([]T, L)
 	A := make([]int, L)
+	for l := range A {
+		A[l] = -1
+	}
 
-	jb := 16
-	for jj := 0; jj < N-jb+1; jj += jb {
-		yi := make([]T, jb)
-		for j := jj; j < jj+jb; j++ {
-			yi[j-jj] = scores[j]
+	for j := lo; j < hi; j++ {
+		if !s.keep(j) {
+			continue
 		}
-		for j := jj; j < jj+jb; j++ {
-			l := (j >> uint(bs))
-			b := yi[j-jj] > V[l]
-			if b {
-				V[l] = yi[j-jj]
-				A[l] = j
-			}
+		l := j/bs - first
+		if A[l] < 0 || scores[j] > V[l] {
+			V[l] = scores[j]
+			A[l] = j
 		}
 	}
 
-	// Temporary fix to handle the last block, idk
-	for jj := N - N%jb; jj < N; jj++ {
-		yi := scores[jj]
-		l := (jj >> uint(bs))
-		b := yi > V[l]
-		if b {
-			V[l] = yi
-			A[l] = jj
-		}
-	}
+	return V, A
+}
 
-	indices := make([]int, k)
-	values := make([]T, k)
-	for i := 0; i < k; i++ {
-		maxValue := T(-1e9)
-		maxIndex := -1
-		for j := 0; j < N; j++ {
-			if scores[j] > maxValue {
-				maxValue = scores[j]
-				maxIndex = j
-			}
-		}
-		indices[i] = maxIndex
-		values[i] = maxValue
-		scores[maxIndex] = T(-1e9) // Mark this score as used
+// ExpectedRecall estimates the recall of MIPS with the given bin size,
+// L/k·(1-(1-1/L)^k) for L bins, assuming the top k rows fall into bins
+// independently (see https://arxiv.org/pdf/2206.14286).
+func (s *Search[T]) ExpectedRecall(k int, binSize int) float64 {
+	if s.Data == nil || k <= 0 || binSize <= 0 {
+		return 0
+	}
+	if binSize == 1 {
+		return 1
 	}
 
-	return Neighbors[T]{Values: values, Indices: indices}, nil
+	L := float64((s.Data.Shape[0] + binSize - 1) / binSize)
+	return math.Min(1, L/float64(k)*(1-math.Pow(1-1/L, float64(k))))
 }
 
 func (s *Search[T]) checker(k int) error {
-	if k <= 0 || k > len(s.Data.Values.([][]T)) {
+	if err := s.rangeChecker(); err != nil {
+		return err
+	}
+
+	return s.kChecker(k)
+}
+
+func (s *Search[T]) kChecker(k int) error {
+	if k <= 0 || k > s.Data.Shape[0] {
 		return errors.New("k must be greater than 0 and less than the length of the data")
 	}
+
+	if s.Filter != nil && k > s.kept() {
+		return errors.New("k must be less than the number of rows passing the filter")
+	}
+
+	return nil
+}
+
+func (s *Search[T]) rangeChecker() error {
+	if err := s.shapeChecker(); err != nil {
+		return err
+	}
+
+	if s.binary() {
+		return errors.New("binary tensors only support Hamming")
+	}
+
+	return s.weightsChecker(s.Query.Shape[0])
+}
+
+func (s *Search[T]) shapeChecker() error {
 	if s.Data == nil || s.Query == nil {
 		return errors.New("data and query tensors must be initialized")
 	}
@@ -217,6 +577,65 @@ func (s *Search[T]) checker(k int) error {
 	return nil
 }
 
+func (s *Search[T]) batchChecker(k int) error {
+	if s.Data == nil || s.Query == nil {
+		return errors.New("data and query tensors must be initialized")
+	}
+
+	if s.Data.Rank != 2 || s.Query.Rank != 2 {
+		return errors.New("data and query must be matrices")
+	}
+
+	if s.Data.Shape[1] != s.Query.Shape[1] {
+		return errors.New("data and query dimensions do not match")
+	}
+
+	_, data := s.Data.Values.([][]uint64)
+	_, query := s.Query.Values.([][]uint64)
+	if data != query {
+		return errors.New("data and query must both be binary")
+	}
+	if data {
+		return errors.New("binary tensors only support Hamming")
+	}
+
+	if err := s.weightsChecker(s.Query.Shape[1]); err != nil {
+		return err
+	}
+
+	return s.kChecker(k)
+}
+
+func (s *Search[T]) weightsChecker(dim int) error {
+	if s.Weights == nil {
+		return nil
+	}
+
+	if s.Weights.Rank != 1 {
+		return errors.New("weights must be a vector")
+	}
+
+	if s.Weights.Shape[0] != dim {
+		return errors.New("weights and query dimensions do not match")
+	}
+
+	return nil
+}
+
+// single returns a copy of s searching only row q of a batched query.
+func (s *Search[T]) single(q int) *Search[T] {
+	row := s.Query.Row(q)
+
+	c := *s
+	c.Query = &Tensor[T]{
+		Values: row,
+		Shape:  [2]int{len(row)},
+		Type:   s.Query.Type,
+		Rank:   1,
+	}
+	return &c
+}
+
 func (s *Search[T]) ret(k *int, h *MaxHeap[T]) (Neighbors[T], error) {
 	indices := make([]int, *k)
 	values := make([]T, *k)
@@ -234,6 +653,22 @@ func (s *Search[T]) PrintDistances() {
 	fmt.Println("\t1. L1(k int)")
 	fmt.Println("\t2. L2(k int)")
 	fmt.Println("\t3. MIPS(k int, ?bin_size int)")
+	fmt.Println("\t4. Cosine(k int)")
+	fmt.Println("\t5. Chebyshev(k int)")
+	fmt.Println("\t6. Minkowski(k int, p T)")
+	fmt.Println("\t7. Hamming(k int)")
+	fmt.Println("\t8. Jaccard(k int)")
+	fmt.Println("\t9. Canberra(k int)")
+	fmt.Println("\t10. BrayCurtis(k int)")
+	fmt.Println("\t11. Mahalanobis(k int)")
+	fmt.Println("\t12. Custom(k int, metric Metric[T])")
+	fmt.Println("\t13. RangeL1(radius T, ?limit int)")
+	fmt.Println("\t14. RangeL2(radius T, ?limit int)")
+	fmt.Println("\t15. RangeMIPS(threshold T, ?limit int)")
+	fmt.Println("\t16. BatchL1(k int)")
+	fmt.Println("\t17. BatchL2(k int)")
+	fmt.Println("\t18. BatchMIPS(k int, ?bin_size int)")
+	fmt.Println("\t19. BatchCosine(k int)")
 }
 
 func (s *Search[T]) GetSize() T {
@@ -242,17 +677,48 @@ func (s *Search[T]) GetSize() T {
 		return size
 	}
 	size += T(unsafe.Sizeof(s))
-	size += T(unsafe.Sizeof(s.Data.Values.([][]T)))
-	for _, row := range s.Data.Values.([][]T) {
-		size += T(unsafe.Sizeof(row))
-		size += T(len(row)) * T(unsafe.Sizeof(T(0)))
+	switch values := s.Data.Values.(type) {
+	case [][]T:
+		size += T(unsafe.Sizeof(values))
+		for _, row := range values {
+			size += T(unsafe.Sizeof(row))
+			size += T(len(row)) * T(unsafe.Sizeof(T(0)))
+		}
+	case *Int8Matrix[T]:
+		size += T(unsafe.Sizeof(*values))
+		for _, row := range values.Codes {
+			size += T(unsafe.Sizeof(row))
+			size += T(len(row))
+		}
+		size += T(len(values.Scale)+len(values.Offset)) * T(unsafe.Sizeof(T(0)))
+	case [][]Float16:
+		size += T(unsafe.Sizeof(values))
+		for _, row := range values {
+			size += T(unsafe.Sizeof(row))
+			size += T(len(row)) * T(unsafe.Sizeof(Float16(0)))
+		}
+	case [][]uint64:
+		size += T(unsafe.Sizeof(values))
+		for _, row := range values {
+			size += T(unsafe.Sizeof(row))
+			size += T(len(row)) * T(unsafe.Sizeof(uint64(0)))
+		}
+	case nil:
+		flat, _ := s.Data.Flat()
+		size += T(len(flat)) * T(unsafe.Sizeof(T(0)))
 	}
 	size += T(unsafe.Sizeof(s.Data.Shape) * 2)
 	size += T(unsafe.Sizeof(s.Data.Type))
 	size += T(unsafe.Sizeof(s.Data.Rank))
 
-	size += T(unsafe.Sizeof(s.Query.Values.([]T)))
-	size += T(len(s.Query.Values.([]T))) * T(unsafe.Sizeof(T(0)))
+	switch values := s.Query.Values.(type) {
+	case []T:
+		size += T(unsafe.Sizeof(values))
+		size += T(len(values)) * T(unsafe.Sizeof(T(0)))
+	case []uint64:
+		size += T(unsafe.Sizeof(values))
+		size += T(len(values)) * T(unsafe.Sizeof(uint64(0)))
+	}
 	size += T(unsafe.Sizeof(s.Query.Shape))
 	size += T(unsafe.Sizeof(s.Query.Type))
 	size += T(unsafe.Sizeof(s.Query.Rank))
@@ -269,6 +735,11 @@ func (s *Search[T]) PrintTypes() {
   Query: *Tensor[T],
   Multithread: bool,
   MaxWorkers: int,
+  SIMD: bool,
+  Normalized: bool,
+  Weights: *Tensor[T],
+  InvCov: *Tensor[T],
+  Filter: func(index int) bool,
 }`)
 }
 
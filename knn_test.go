@@ -1,8 +1,11 @@
 package knn
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -92,6 +95,57 @@ func TestSearch(t *testing.T) {
 		}
 	})
 
+	t.Run("Cosine", func(t *testing.T) {
+		neighbors, err := s.Cosine(2)
+		if err != nil {
+			t.Fatalf("Cosine search failed: %v", err)
+		}
+
+		expectedIndices := []int{1, 2}
+		expectedValues := []float32{0.99922048, 0.99503924}
+
+		if !reflect.DeepEqual(neighbors.Indices, expectedIndices) {
+			t.Errorf("Cosine indices mismatch. Got %v, want %v", neighbors.Indices, expectedIndices)
+		}
+
+		for i, v := range neighbors.Values {
+			if math.Abs(float64(v-expectedValues[i])) > 1e-6 {
+				t.Errorf("Cosine value mismatch at index %d. Got %f, want %f", i, v, expectedValues[i])
+			}
+		}
+	})
+
+	t.Run("Cosine Normalized", func(t *testing.T) {
+		normalized := make([][]float32, len(data))
+		for i := range data {
+			normalized[i] = append([]float32{}, data[i]...)
+		}
+		normalizedTensor := &Tensor[float32]{}
+		_ = normalizedTensor.New(normalized)
+		_ = normalizedTensor.Normalize()
+
+		ns := &Search[float32]{
+			Data:       normalizedTensor,
+			Query:      queryTensor,
+			Normalized: true,
+		}
+
+		got, err := ns.Cosine(2)
+		if err != nil {
+			t.Fatalf("Cosine search failed: %v", err)
+		}
+		want, _ := s.Cosine(2)
+
+		if !reflect.DeepEqual(got.Indices, want.Indices) {
+			t.Errorf("Cosine indices mismatch. Got %v, want %v", got.Indices, want.Indices)
+		}
+		for i, v := range got.Values {
+			if math.Abs(float64(v-want.Values[i])) > 1e-6 {
+				t.Errorf("Cosine value mismatch at index %d. Got %f, want %f", i, v, want.Values[i])
+			}
+		}
+	})
+
 	t.Run("Error Cases", func(t *testing.T) {
 		_, err := s.L1(0)
 		if err == nil {
@@ -119,6 +173,420 @@ func TestSearch(t *testing.T) {
 	})
 }
 
+func TestBatchSearch(t *testing.T) {
+	data := [][]float32{
+		{1.0, 2.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{7.0, 8.0, 9.0},
+		{10.0, 11.0, 12.0},
+	}
+	queries := [][]float32{
+		{3.0, 4.0, 5.0},
+		{9.0, 9.0, 9.0},
+		{0.0, 1.0, 0.0},
+	}
+
+	dataTensor := &Tensor[float32]{}
+	if err := dataTensor.New(data); err != nil {
+		t.Fatalf("Failed to create data tensor: %v", err)
+	}
+
+	queryTensor := &Tensor[float32]{}
+	if err := queryTensor.New(queries); err != nil {
+		t.Fatalf("Failed to create query tensor: %v", err)
+	}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        dataTensor,
+			Query:       queryTensor,
+			Multithread: multithread,
+		}
+
+		methods := []struct {
+			name   string
+			batch  func(k int) ([]Neighbors[float32], error)
+			single func(s *Search[float32], k int) (Neighbors[float32], error)
+		}{
+			{"L1", s.BatchL1, (*Search[float32]).L1},
+			{"L2", s.BatchL2, (*Search[float32]).L2},
+			{"MIPS", func(k int) ([]Neighbors[float32], error) { return s.BatchMIPS(k) },
+				func(s *Search[float32], k int) (Neighbors[float32], error) { return s.MIPS(k) }},
+			{"Cosine", s.BatchCosine, (*Search[float32]).Cosine},
+		}
+
+		for _, m := range methods {
+			t.Run(fmt.Sprintf("%s multithread=%v", m.name, multithread), func(t *testing.T) {
+				batch, err := m.batch(2)
+				if err != nil {
+					t.Fatalf("Batch%s search failed: %v", m.name, err)
+				}
+				if len(batch) != len(queries) {
+					t.Fatalf("Expected %d results, got %d", len(queries), len(batch))
+				}
+
+				for q, query := range queries {
+					queryRow := &Tensor[float32]{}
+					_ = queryRow.New(query)
+					want, err := m.single(&Search[float32]{Data: dataTensor, Query: queryRow}, 2)
+					if err != nil {
+						t.Fatalf("%s search failed: %v", m.name, err)
+					}
+
+					if !reflect.DeepEqual(batch[q].Indices, want.Indices) {
+						t.Errorf("Query %d indices mismatch. Got %v, want %v", q, batch[q].Indices, want.Indices)
+					}
+					for i, v := range batch[q].Values {
+						if math.Abs(float64(v-want.Values[i])) > 1e-4 {
+							t.Errorf("Query %d value mismatch at index %d. Got %f, want %f", q, i, v, want.Values[i])
+						}
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("Error Cases", func(t *testing.T) {
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		if _, err := s.BatchL2(0); err == nil {
+			t.Error("Expected error for k=0, got nil")
+		}
+
+		vectorTensor := &Tensor[float32]{}
+		_ = vectorTensor.New(queries[0])
+		s.Query = vectorTensor
+		if _, err := s.BatchL2(1); err == nil {
+			t.Error("Expected error for rank-1 query, got nil")
+		}
+
+		invalidTensor := &Tensor[float32]{}
+		_ = invalidTensor.New([][]float32{{1.0, 2.0}})
+		s.Query = invalidTensor
+		if _, err := s.BatchL1(1); err == nil {
+			t.Error("Expected error for mismatched dimensions, got nil")
+		}
+	})
+}
+
+func TestMetricSearch(t *testing.T) {
+	data := [][]float32{
+		{1.0, 0.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{3.0, 4.0, 9.0},
+		{2.0, 1.0, 2.0},
+		{0.0, 0.0, 0.0},
+	}
+	query := []float32{3.0, 4.0, 5.0}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+
+	tests := []struct {
+		name   string
+		search func(s *Search[float32], k int) (Neighbors[float32], error)
+		kernel func(a, b []float32) float32
+	}{
+		{"Chebyshev", (*Search[float32]).Chebyshev, chebyshev[float32]},
+		{"Minkowski", func(s *Search[float32], k int) (Neighbors[float32], error) { return s.Minkowski(k, 3) },
+			func(a, b []float32) float32 { return minkowski(a, b, 3) }},
+		{"Hamming", (*Search[float32]).Hamming, hamming[float32]},
+		{"Jaccard", (*Search[float32]).Jaccard, jaccard[float32]},
+		{"Canberra", (*Search[float32]).Canberra, canberra[float32]},
+		{"BrayCurtis", (*Search[float32]).BrayCurtis, braycurtis[float32]},
+	}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        dataTensor,
+			Query:       queryTensor,
+			Multithread: multithread,
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s multithread=%v", tt.name, multithread), func(t *testing.T) {
+				k := 3
+				neighbors, err := tt.search(s, k)
+				if err != nil {
+					t.Fatalf("%s search failed: %v", tt.name, err)
+				}
+
+				distances := make([]float32, len(data))
+				for i := range data {
+					distances[i] = tt.kernel(query, data[i])
+				}
+				sorted := append([]float32{}, distances...)
+				sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+				for i, index := range neighbors.Indices {
+					if neighbors.Values[i] != distances[index] {
+						t.Errorf("Value at %d does not match row %d. Got %f, want %f", i, index, neighbors.Values[i], distances[index])
+					}
+					if neighbors.Values[i] != sorted[i] {
+						t.Errorf("Value at %d is not the %d-th smallest. Got %f, want %f", i, i, neighbors.Values[i], sorted[i])
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("Invalid p", func(t *testing.T) {
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		if _, err := s.Minkowski(2, 0.5); err == nil {
+			t.Error("Expected error for p < 1, got nil")
+		}
+	})
+}
+
+func TestWeightedSearch(t *testing.T) {
+	data := [][]float32{
+		{0.0, 9.0},
+		{1.0, 0.0},
+		{5.0, 1.0},
+	}
+	query := []float32{0.0, 0.0}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+	weightsTensor := &Tensor[float32]{}
+	_ = weightsTensor.New([]float32{1.0, 0.0})
+
+	s := &Search[float32]{
+		Data:    dataTensor,
+		Query:   queryTensor,
+		Weights: weightsTensor,
+	}
+
+	t.Run("L1", func(t *testing.T) {
+		neighbors, err := s.L1(2)
+		if err != nil {
+			t.Fatalf("L1 search failed: %v", err)
+		}
+
+		expectedIndices := []int{0, 1}
+		expectedValues := []float32{0, 1}
+		if !reflect.DeepEqual(neighbors.Indices, expectedIndices) {
+			t.Errorf("L1 indices mismatch. Got %v, want %v", neighbors.Indices, expectedIndices)
+		}
+		if !reflect.DeepEqual(neighbors.Values, expectedValues) {
+			t.Errorf("L1 values mismatch. Got %v, want %v", neighbors.Values, expectedValues)
+		}
+	})
+
+	t.Run("Cosine Normalized", func(t *testing.T) {
+		unit := &Tensor[float32]{}
+		_ = unit.New([][]float32{{0.6, 0.8}, {1.0, 0.0}, {0.8, -0.6}})
+		query := &Tensor[float32]{}
+		_ = query.New([]float32{1.0, 1.0})
+		weights := &Tensor[float32]{}
+		_ = weights.New([]float32{4.0, 1.0})
+
+		want, err := (&Search[float32]{Data: unit, Query: query, Weights: weights}).Cosine(3)
+		if err != nil {
+			t.Fatalf("Cosine search failed: %v", err)
+		}
+		got, err := (&Search[float32]{Data: unit, Query: query, Weights: weights, Normalized: true}).Cosine(3)
+		if err != nil {
+			t.Fatalf("Cosine search failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Normalized weighted cosine mismatch. Got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("L2", func(t *testing.T) {
+		neighbors, err := s.L2(2)
+		if err != nil {
+			t.Fatalf("L2 search failed: %v", err)
+		}
+
+		expectedIndices := []int{0, 1}
+		expectedValues := []float32{0, 0.5}
+		if !reflect.DeepEqual(neighbors.Indices, expectedIndices) {
+			t.Errorf("L2 indices mismatch. Got %v, want %v", neighbors.Indices, expectedIndices)
+		}
+		if !reflect.DeepEqual(neighbors.Values, expectedValues) {
+			t.Errorf("L2 values mismatch. Got %v, want %v", neighbors.Values, expectedValues)
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		invalidWeights := &Tensor[float32]{}
+		_ = invalidWeights.New([]float32{1.0, 1.0, 1.0})
+		invalidSearch := &Search[float32]{Data: dataTensor, Query: queryTensor, Weights: invalidWeights}
+		if _, err := invalidSearch.L1(1); err == nil {
+			t.Error("Expected error for mismatched weights, got nil")
+		}
+
+		matrixWeights := &Tensor[float32]{}
+		_ = matrixWeights.New([][]float32{{1.0, 1.0}})
+		invalidSearch.Weights = matrixWeights
+		if _, err := invalidSearch.L2(1); err == nil {
+			t.Error("Expected error for rank-2 weights, got nil")
+		}
+
+		for _, metric := range []int{Chebyshev, Hamming, Jaccard, Canberra, BrayCurtis, Mahalanobis} {
+			if _, err := s.metric(1, metric); err == nil {
+				t.Errorf("Expected error for weights with metric %d, got nil", metric)
+			}
+		}
+		if _, err := s.Minkowski(1, 3); err == nil {
+			t.Error("Expected error for weights with Minkowski, got nil")
+		}
+		if _, err := s.Custom(1, DistanceFunc[float32](chebyshev[float32])); err == nil {
+			t.Error("Expected error for weights with Custom, got nil")
+		}
+	})
+}
+
+func TestMIPSBinReduction(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1024, 64)
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	exactTop := func(scores []float32, k int) []int {
+		indices := make([]int, len(scores))
+		for i := range indices {
+			indices[i] = i
+		}
+		sort.SliceStable(indices, func(a, b int) bool { return scores[indices[a]] > scores[indices[b]] })
+		return indices[:k]
+	}
+
+	t.Run("Bin winners", func(t *testing.T) {
+		total := 0.0
+		queries := randomMatrix(r, 50, 64)
+		for _, q := range queries {
+			queryTensor := &Tensor[float32]{}
+			_ = queryTensor.New(q)
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+
+			got, err := s.MIPS(10, 64)
+			if err != nil {
+				t.Fatalf("MIPS search failed: %v", err)
+			}
+
+			scores := s.Einsum()
+			for i, index := range got.Indices {
+				if i > 0 && got.Values[i] > got.Values[i-1] {
+					t.Errorf("Values not sorted: %v", got.Values)
+				}
+				if got.Values[i] != scores[index] {
+					t.Errorf("Value at %d mismatch. Got %f, want %f", i, got.Values[i], scores[index])
+				}
+				bin := index / 64
+				for j := bin * 64; j < (bin+1)*64; j++ {
+					if scores[j] > scores[index] {
+						t.Fatalf("Row %d is not the best of bin %d", index, bin)
+					}
+				}
+			}
+
+			found := make(map[int]bool)
+			for _, i := range got.Indices {
+				found[i] = true
+			}
+			for _, i := range exactTop(scores, 10) {
+				if found[i] {
+					total++
+				}
+			}
+		}
+
+		expected := (&Search[float32]{Data: dataTensor}).ExpectedRecall(10, 64)
+		if avg := total / float64(10*len(queries)); avg < expected-0.15 {
+			t.Errorf("Recall %f is well below the expected %f", avg, expected)
+		}
+	})
+
+	t.Run("Multithread and SIMD", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(data[3])
+		filter := func(i int) bool { return i%3 != 0 }
+
+		for _, bs := range []int{1, 4, 64} {
+			for _, workers := range []int{1, 3, 7, 2000} {
+				single := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter}
+				want, _ := single.MIPS(10, bs)
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter, Multithread: true, MaxWorkers: workers}
+				got, err := s.MIPS(10, bs)
+				if err != nil {
+					t.Fatalf("MIPS search failed: %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("bin_size %d, %d workers: got %v, want %v", bs, workers, got.Indices, want.Indices)
+				}
+			}
+
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor, SIMD: true, Multithread: true}
+			got, _ := s.MIPS(10, bs)
+			want, _ := (&Search[float32]{Data: dataTensor, Query: queryTensor}).MIPS(10, bs)
+			for i := range want.Values {
+				if math.Abs(float64(got.Values[i]-want.Values[i])) > 1e-3 {
+					t.Errorf("bin_size %d SIMD value at %d: got %f, want %f", bs, i, got.Values[i], want.Values[i])
+				}
+			}
+		}
+	})
+
+	t.Run("Negative scores", func(t *testing.T) {
+		negative := [][]float32{{-1e5, -1e5}, {-3e5, -3e5}, {-2e5, -2e5}, {-4e5, -4e5}}
+		negativeTensor := &Tensor[float32]{}
+		_ = negativeTensor.New(negative)
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New([]float32{1e5, 1e5})
+
+		for _, bs := range []int{1, 2} {
+			s := &Search[float32]{Data: negativeTensor, Query: queryTensor}
+			got, err := s.MIPS(2, bs)
+			if err != nil {
+				t.Fatalf("MIPS search failed: %v", err)
+			}
+			if !reflect.DeepEqual(got.Indices, []int{0, 2}) || got.Values[0] != -2e10 {
+				t.Errorf("bin_size %d: got %v %v, want [0 2] [-2e10 -4e10]", bs, got.Indices, got.Values)
+			}
+		}
+	})
+
+	t.Run("Fewer bins than k", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(data[0])
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+
+		got, err := s.MIPS(20, 64)
+		if err != nil {
+			t.Fatalf("MIPS search failed: %v", err)
+		}
+		if want := exactTop(s.Einsum(), 20); !reflect.DeepEqual(got.Indices, want) {
+			t.Errorf("Got %v, want exact %v", got.Indices, want)
+		}
+	})
+
+	t.Run("Expected recall", func(t *testing.T) {
+		s := &Search[float32]{Data: dataTensor}
+		tests := []struct {
+			k, binSize int
+			expected   float64
+		}{
+			{10, 1, 1},
+			{10, 64, 16.0 / 10 * (1 - math.Pow(15.0/16, 10))},
+			{1, 8, 1},
+			{0, 8, 0},
+		}
+		for _, tt := range tests {
+			if got := s.ExpectedRecall(tt.k, tt.binSize); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("ExpectedRecall(%d, %d) = %f, want %f", tt.k, tt.binSize, got, tt.expected)
+			}
+		}
+	})
+}
+
 func BenchmarkSearch(b *testing.B) {
 	data := make([][]float32, 10000)
 	for i := range data {
@@ -198,10 +666,9 @@ func BenchmarkLargeMultithreadedSearch(b *testing.B) {
 		}
 	})
 
-	/* No multithreaded MIPS implementation
 	b.Run("MIPS", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			_, _ = s.MIPS(10)
 		}
-	})*/
+	})
 }
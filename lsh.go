@@ -0,0 +1,200 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// LSH is a locality-sensitive hashing index. Cosine uses random hyperplane
+// hashes and L2 uses p-stable projections, see
+// https://dl.acm.org/doi/10.1145/997817.997857
+// Candidates from every table are re-ranked exactly.
+type LSH[T float32 | float64] struct {
+	Tables int     // number of hash tables (default = 8)
+	Bits   int     // hash width, projections per table, at most 64 (default = 12)
+	Width  float64 // bucket width of the L2 projections (default = 4)
+	Metric int     // Cosine or L2
+	Seed   int64   // seed for the projections
+
+	mu      sync.RWMutex
+	rows    [][]T
+	norms   []T
+	planes  [][][]T // planes[table][bit]
+	offsets [][]T
+	buckets []map[uint64][]int
+}
+
+func (l *LSH[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+	if !data.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+
+	l.mu.Lock()
+	l.rows = nil
+	l.norms = nil
+	l.planes = nil
+	l.mu.Unlock()
+
+	for _, row := range rows {
+		if _, err := l.insert(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Insert hashes a copy of a vector into every table and returns its index.
+func (l *LSH[T]) Insert(vector []T) (int, error) {
+	return l.insert(append([]T(nil), vector...))
+}
+
+// insert hashes a vector into every table without copying it.
+func (l *LSH[T]) insert(vector []T) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(vector) == 0 {
+		return 0, errors.New("empty values")
+	}
+	if l.planes == nil {
+		if err := l.init(len(vector)); err != nil {
+			return 0, err
+		}
+	}
+	if len(vector) != len(l.planes[0][0]) {
+		return 0, errors.New("vector and data dimensions do not match")
+	}
+
+	id := len(l.rows)
+	l.rows = append(l.rows, vector)
+	l.norms = append(l.norms, norm(vector))
+	for t := range l.buckets {
+		key := l.hash(t, vector)
+		l.buckets[t][key] = append(l.buckets[t][key], id)
+	}
+
+	return id, nil
+}
+
+func (l *LSH[T]) init(dim int) error {
+	if l.Metric != Cosine && l.Metric != L2 {
+		return fmt.Errorf("unsupported metric: %d", l.Metric)
+	}
+	if l.Tables <= 0 {
+		l.Tables = 8
+	}
+	if l.Bits <= 0 {
+		l.Bits = 12
+	}
+	if l.Bits > 64 {
+		return errors.New("bits must be at most 64")
+	}
+	if l.Width <= 0 {
+		l.Width = 4
+	}
+
+	rng := rand.New(rand.NewSource(l.Seed))
+	l.planes = make([][][]T, l.Tables)
+	l.offsets = make([][]T, l.Tables)
+	l.buckets = make([]map[uint64][]int, l.Tables)
+	for t := range l.planes {
+		l.planes[t] = make([][]T, l.Bits)
+		l.offsets[t] = make([]T, l.Bits)
+		for b := range l.planes[t] {
+			l.planes[t][b] = make([]T, dim)
+			for j := range l.planes[t][b] {
+				l.planes[t][b][j] = T(rng.NormFloat64())
+			}
+			l.offsets[t][b] = T(rng.Float64() * l.Width)
+		}
+		l.buckets[t] = make(map[uint64][]int)
+	}
+
+	return nil
+}
+
+// hash packs one sign bit per hyperplane for Cosine, and mixes the bucket
+// numbers floor((a·x + b) / Width) of every projection for L2.
+func (l *LSH[T]) hash(t int, vector []T) uint64 {
+	var key uint64
+	for b, plane := range l.planes[t] {
+		p := dot(plane, vector)
+		if l.Metric == Cosine {
+			if p > 0 {
+				key |= 1 << uint(b)
+			}
+			continue
+		}
+		bucket := int64(math.Floor(float64(p+l.offsets[t][b]) / l.Width))
+		key = (key ^ uint64(bucket)) * 1099511628211
+	}
+	return key
+}
+
+// Search re-ranks the rows sharing a bucket with the query in any table
+// and returns up to k of them. Values are Euclidean distances, nearest
+// first, for L2 and similarities, highest first, for Cosine.
+func (l *LSH[T]) Search(query *Tensor[T], k int) (Neighbors[T], error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.planes == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+	if query == nil || query.Rank != 1 {
+		return Neighbors[T]{}, errors.New("query must be a vector")
+	}
+	vector := query.Values.([]T)
+	if len(vector) != len(l.planes[0][0]) {
+		return Neighbors[T]{}, errors.New("data and query dimensions do not match")
+	}
+	if k <= 0 {
+		return Neighbors[T]{}, errors.New("k must be greater than 0")
+	}
+
+	qnorm := norm(vector)
+	seen := NewBitset(len(l.rows))
+	h := &MaxHeap[T]{}
+	heap.Init(h)
+
+	for t := range l.buckets {
+		for _, id := range l.buckets[t][l.hash(t, vector)] {
+			if seen.Has(id) {
+				continue
+			}
+			seen.Set(id)
+
+			var distance T
+			if l.Metric == L2 {
+				distance = squaredEuclidean(vector, l.rows[id])
+			} else if qnorm != 0 && l.norms[id] != 0 {
+				distance = -dot(vector, l.rows[id]) / (qnorm * l.norms[id])
+			}
+			h.Process(&id, &k, &distance)
+		}
+	}
+
+	n := h.Len()
+	nn, err := (&Search[T]{}).ret(&n, h)
+	if err != nil {
+		return nn, err
+	}
+	if l.Metric == Cosine {
+		return negate(nn, nil)
+	}
+
+	for i, v := range nn.Values {
+		nn.Values[i] = T(math.Sqrt(float64(v)))
+	}
+
+	return nn, nil
+}
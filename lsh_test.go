@@ -0,0 +1,130 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLSH(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1000, 8)
+	for _, row := range data {
+		for j := range row {
+			row[j] -= 0.5
+		}
+	}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	queries := randomMatrix(r, 20, 8)
+	for _, row := range queries {
+		for j := range row {
+			row[j] -= 0.5
+		}
+	}
+
+	tests := []struct {
+		name   string
+		lsh    *LSH[float32]
+		metric int
+	}{
+		{"L2", &LSH[float32]{Tables: 16, Bits: 3, Width: 1, Metric: L2, Seed: 1}, L2},
+		{"Cosine", &LSH[float32]{Tables: 16, Bits: 6, Metric: Cosine, Seed: 1}, Cosine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.lsh.New(dataTensor); err != nil {
+				t.Fatalf("Failed to build LSH: %v", err)
+			}
+
+			total := 0.0
+			for _, q := range queries {
+				queryTensor := &Tensor[float32]{}
+				_ = queryTensor.New(q)
+
+				got, err := tt.lsh.Search(queryTensor, 10)
+				if err != nil {
+					t.Fatalf("LSH search failed: %v", err)
+				}
+
+				for i, index := range got.Indices {
+					expected := math.Sqrt(float64(squaredEuclidean(q, data[index])))
+					if tt.metric == Cosine {
+						expected = float64(dot(q, data[index]) / (norm(q) * norm(data[index])))
+					}
+					if math.Abs(float64(got.Values[i])-expected) > 1e-4 {
+						t.Errorf("Value at %d mismatch. Got %f, want %f", i, got.Values[i], expected)
+					}
+				}
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+				want, _ := s.metric(10, tt.metric)
+				found := make(map[int]bool)
+				for _, i := range got.Indices {
+					found[i] = true
+				}
+				for _, i := range want.Indices {
+					if found[i] {
+						total++
+					}
+				}
+			}
+
+			if avg := total / float64(10*len(queries)); avg < 0.6 {
+				t.Errorf("Expected recall of at least 0.6, got %f", avg)
+			}
+		})
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		lsh := &LSH[float32]{Metric: L2, Seed: 1}
+		if err := lsh.New(dataTensor); err != nil {
+			t.Fatalf("Failed to build LSH: %v", err)
+		}
+
+		vector := []float32{3, 3, 3, 3, 3, 3, 3, 3}
+		id, err := lsh.Insert(vector)
+		if err != nil || id != len(data) {
+			t.Fatalf("Insert() = %d, %v, want %d", id, err, len(data))
+		}
+
+		// the caller may reuse its buffer
+		for j := range vector {
+			vector[j] = 0
+		}
+
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New([]float32{3, 3, 3, 3, 3, 3, 3, 3})
+		got, err := lsh.Search(queryTensor, 1)
+		if err != nil || len(got.Indices) != 1 || got.Indices[0] != id || got.Values[0] != 0 {
+			t.Errorf("Search() = %v, %v, want [%d] [0]", got, err, id)
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+
+		if err := (&LSH[float32]{Metric: MIPS}).New(dataTensor); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+		if err := (&LSH[float32]{Metric: Cosine, Bits: 65}).New(dataTensor); err == nil {
+			t.Error("Expected error for hash width above 64, got nil")
+		}
+		if _, err := (&LSH[float32]{}).Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for empty index, got nil")
+		}
+
+		lsh := &LSH[float32]{Metric: L2}
+		_ = lsh.New(dataTensor)
+		if _, err := lsh.Insert([]float32{1, 2}); err == nil {
+			t.Error("Expected error for dimension mismatch, got nil")
+		}
+		if _, err := lsh.Search(queryTensor, 0); err == nil {
+			t.Error("Expected error for invalid k, got nil")
+		}
+	})
+}
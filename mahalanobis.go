@@ -0,0 +1,238 @@
+package knn
+
+import (
+	"errors"
+	"math"
+)
+
+// Mahalanobis searches by sqrt((q-x)ᵀ Σ⁻¹ (q-x)), using InvCov as Σ⁻¹ or the
+// covariance of Data when InvCov is nil. The data is whitened once and
+// cached, so every query reduces to the L2 path.
+func (s *Search[T]) Mahalanobis(k int) (Neighbors[T], error) {
+	if err := s.checker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
+	if s.Weights != nil {
+		return Neighbors[T]{}, errors.New("weights only support L1, L2, MIPS and Cosine")
+	}
+
+	if err := s.whiten(); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	query := s.whitener.apply(s.Query.Values.([]T))
+	qnorm := T(0)
+	for _, x := range query {
+		qnorm += x * x
+	}
+
+	w := &Search[T]{
+		Data:        s.whitened,
+		Query:       &Tensor[T]{Values: query, Shape: [2]int{len(query)}, Type: s.Query.Type, Rank: 1},
+		Multithread: s.Multithread,
+		MaxWorkers:  s.MaxWorkers,
+		SIMD:        s.SIMD,
+		Filter:      s.Filter,
+		halfnorm:    s.whitenedHalfnorm,
+	}
+
+	nn, err := w.L2(k)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	// L2 ranks by |x|²/2 - q·x, recover the distance
+	for i, v := range nn.Values {
+		nn.Values[i] = T(math.Sqrt(math.Max(0, float64(2*v+qnorm))))
+	}
+
+	return nn, nil
+}
+
+type whitener[T float32 | float64] [][]float64
+
+func (w whitener[T]) apply(x []T) []T {
+	result := make([]T, len(w))
+	for i, row := range w {
+		var sum float64
+		for j, v := range row {
+			sum += v * float64(x[j])
+		}
+		result[i] = T(sum)
+	}
+	return result
+}
+
+func (s *Search[T]) whiten() error {
+	key := [2]*Tensor[T]{s.Data, s.InvCov}
+	if s.whitened != nil && s.whitenedOf == key {
+		return nil
+	}
+
+	if !s.Data.dense() {
+		return errors.New("quantized data only supports L1, L2, MIPS and Cosine")
+	}
+
+	dim := s.Data.Shape[1]
+
+	var w whitener[T]
+	if s.InvCov != nil {
+		if s.InvCov.Rank != 2 || s.InvCov.Shape != [2]int{dim, dim} {
+			return errors.New("inverse covariance must be a square matrix matching the data dimensions")
+		}
+
+		// Σ⁻¹ = LLᵀ, so |Lᵀ(q-x)|² is the squared distance
+		l, err := cholesky(toFloat64(s.InvCov.rows()))
+		if err != nil {
+			return err
+		}
+		w = whitener[T](transpose(l))
+	} else {
+		if s.Data.Shape[0] < 2 {
+			return errors.New("at least 2 rows are needed to estimate the covariance")
+		}
+
+		// Σ = LLᵀ, so |L⁻¹(q-x)|² is the squared distance
+		cov := covariance(s.Data.rows())
+		l, err := cholesky(cov)
+		if err != nil {
+			// singular covariance, regularize the diagonal and retry
+			ridge := 0.0
+			for i := range cov {
+				ridge += cov[i][i]
+			}
+			ridge = 1e-6 * (ridge/float64(dim) + 1)
+			for i := range cov {
+				cov[i][i] += ridge
+			}
+			if l, err = cholesky(cov); err != nil {
+				return err
+			}
+		}
+		w = whitener[T](invertLower(l))
+	}
+
+	rows := make([][]T, s.Data.Shape[0])
+	for i := range rows {
+		rows[i] = w.apply(s.Data.Row(i))
+	}
+
+	whitened := &Tensor[T]{}
+	if err := whitened.New(rows); err != nil {
+		return err
+	}
+
+	s.whitener = w
+	s.whitened = whitened
+	s.whitenedHalfnorm = (&Search[T]{Data: whitened, Multithread: s.Multithread, MaxWorkers: s.MaxWorkers}).HalfNorm()
+	s.whitenedOf = key
+
+	return nil
+}
+
+func covariance[T float32 | float64](data [][]T) [][]float64 {
+	n := len(data)
+	dim := len(data[0])
+
+	mean := make([]float64, dim)
+	for _, row := range data {
+		for j, x := range row {
+			mean[j] += float64(x)
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+
+	cov := make([][]float64, dim)
+	for i := range cov {
+		cov[i] = make([]float64, dim)
+	}
+	for _, row := range data {
+		for i := 0; i < dim; i++ {
+			di := float64(row[i]) - mean[i]
+			for j := 0; j <= i; j++ {
+				cov[i][j] += di * (float64(row[j]) - mean[j])
+			}
+		}
+	}
+	for i := 0; i < dim; i++ {
+		for j := 0; j <= i; j++ {
+			cov[i][j] /= float64(n - 1)
+			cov[j][i] = cov[i][j]
+		}
+	}
+
+	return cov
+}
+
+// cholesky returns the lower triangular L with a = LLᵀ.
+func cholesky(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, errors.New("matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+
+	return l, nil
+}
+
+func invertLower(l [][]float64) [][]float64 {
+	n := len(l)
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		inv[i][i] = 1 / l[i][i]
+		for j := 0; j < i; j++ {
+			sum := 0.0
+			for k := j; k < i; k++ {
+				sum -= l[i][k] * inv[k][j]
+			}
+			inv[i][j] = sum / l[i][i]
+		}
+	}
+
+	return inv
+}
+
+func transpose(a [][]float64) [][]float64 {
+	t := make([][]float64, len(a[0]))
+	for i := range t {
+		t[i] = make([]float64, len(a))
+		for j := range a {
+			t[i][j] = a[j][i]
+		}
+	}
+	return t
+}
+
+func toFloat64[T float32 | float64](a [][]T) [][]float64 {
+	result := make([][]float64, len(a))
+	for i, row := range a {
+		result[i] = make([]float64, len(row))
+		for j, x := range row {
+			result[i][j] = float64(x)
+		}
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package knn
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestMahalanobis(t *testing.T) {
+	data := [][]float64{
+		{1.0, 2.0},
+		{2.0, 1.0},
+		{3.0, 5.0},
+		{4.0, 3.0},
+		{6.0, 7.0},
+		{0.0, 1.0},
+	}
+	query := []float64{2.5, 2.5}
+
+	dataTensor := &Tensor[float64]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float64]{}
+	_ = queryTensor.New(query)
+
+	distance := func(vi [][]float64, x []float64) float64 {
+		d := []float64{query[0] - x[0], query[1] - x[1]}
+		return math.Sqrt(d[0]*(vi[0][0]*d[0]+vi[0][1]*d[1]) + d[1]*(vi[1][0]*d[0]+vi[1][1]*d[1]))
+	}
+
+	check := func(t *testing.T, neighbors Neighbors[float64], vi [][]float64) {
+		distances := make([]float64, len(data))
+		for i := range data {
+			distances[i] = distance(vi, data[i])
+		}
+		sorted := append([]float64{}, distances...)
+		sort.Float64s(sorted)
+
+		for i, index := range neighbors.Indices {
+			if math.Abs(neighbors.Values[i]-distances[index]) > 1e-9 {
+				t.Errorf("Value at %d does not match row %d. Got %f, want %f", i, index, neighbors.Values[i], distances[index])
+			}
+			if math.Abs(neighbors.Values[i]-sorted[i]) > 1e-9 {
+				t.Errorf("Value at %d is not the %d-th smallest. Got %f, want %f", i, i, neighbors.Values[i], sorted[i])
+			}
+		}
+	}
+
+	t.Run("Supplied inverse covariance", func(t *testing.T) {
+		vi := [][]float64{{2.0, 0.5}, {0.5, 1.0}}
+		invCov := &Tensor[float64]{}
+		_ = invCov.New(vi)
+
+		s := &Search[float64]{Data: dataTensor, Query: queryTensor, InvCov: invCov}
+		neighbors, err := s.Mahalanobis(3)
+		if err != nil {
+			t.Fatalf("Mahalanobis search failed: %v", err)
+		}
+		check(t, neighbors, vi)
+	})
+
+	t.Run("Estimated covariance", func(t *testing.T) {
+		var mean [2]float64
+		for _, row := range data {
+			mean[0] += row[0] / float64(len(data))
+			mean[1] += row[1] / float64(len(data))
+		}
+		var cov [2][2]float64
+		for _, row := range data {
+			for i := 0; i < 2; i++ {
+				for j := 0; j < 2; j++ {
+					cov[i][j] += (row[i] - mean[i]) * (row[j] - mean[j]) / float64(len(data)-1)
+				}
+			}
+		}
+		det := cov[0][0]*cov[1][1] - cov[0][1]*cov[1][0]
+		vi := [][]float64{
+			{cov[1][1] / det, -cov[0][1] / det},
+			{-cov[1][0] / det, cov[0][0] / det},
+		}
+
+		s := &Search[float64]{Data: dataTensor, Query: queryTensor, Multithread: true}
+		neighbors, err := s.Mahalanobis(3)
+		if err != nil {
+			t.Fatalf("Mahalanobis search failed: %v", err)
+		}
+		check(t, neighbors, vi)
+
+		// the whitened data is reused for the next query
+		whitened := s.whitened
+		if _, err := s.Mahalanobis(2); err != nil {
+			t.Fatalf("Mahalanobis search failed: %v", err)
+		}
+		if s.whitened != whitened {
+			t.Error("Expected whitened data to be cached across queries")
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		invalid := &Tensor[float64]{}
+		_ = invalid.New([][]float64{{1.0, 0.0, 0.0}})
+		s := &Search[float64]{Data: dataTensor, Query: queryTensor, InvCov: invalid}
+		if _, err := s.Mahalanobis(1); err == nil {
+			t.Error("Expected error for mismatched inverse covariance, got nil")
+		}
+
+		notPD := &Tensor[float64]{}
+		_ = notPD.New([][]float64{{1.0, 2.0}, {2.0, 1.0}})
+		s.InvCov = notPD
+		if _, err := s.Mahalanobis(1); err == nil {
+			t.Error("Expected error for non positive definite inverse covariance, got nil")
+		}
+	})
+}
+
+func TestCholesky(t *testing.T) {
+	a := [][]float64{
+		{4, 12, -16},
+		{12, 37, -43},
+		{-16, -43, 98},
+	}
+	expected := [][]float64{
+		{2, 0, 0},
+		{6, 1, 0},
+		{-8, 5, 3},
+	}
+
+	l, err := cholesky(a)
+	if err != nil {
+		t.Fatalf("cholesky failed: %v", err)
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(l[i][j]-expected[i][j]) > 1e-12 {
+				t.Errorf("L[%d][%d] = %v, want %v", i, j, l[i][j], expected[i][j])
+			}
+		}
+	}
+
+	inv := invertLower(l)
+	for i := range l {
+		for j := range l {
+			sum := 0.0
+			for k := range l {
+				sum += inv[i][k] * l[k][j]
+			}
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(sum-want) > 1e-12 {
+				t.Errorf("(L⁻¹L)[%d][%d] = %v, want %v", i, j, sum, want)
+			}
+		}
+	}
+}
@@ -1,45 +1,34 @@
 package knn
 
-import (
-	"runtime"
-	"sync"
-
-	"github.com/alivanz/go-simd/arm"
-	"github.com/alivanz/go-simd/arm/neon"
-)
+import "math"
 
 func (s *Search[T]) Manhattan(i *int) T {
 	query := s.Query.Values.([]T)
-	data := s.Data.Values.([][]T)[*i]
 
-	if s.SIMD {
-		var sum T
-		n := len(query)
-		for j := 0; j < n-3; j += 4 {
-			var a, b arm.Float32X4
-			var sub, abs arm.Float32X4
-
-			for k := 0; k < 4; k++ {
-				a[k] = arm.Float32(query[j+k])
-				b[k] = arm.Float32(data[j+k])
-			}
+	var weights []T
+	if s.Weights != nil {
+		weights = s.Weights.Values.([]T)
+	}
 
-			neon.VsubqF32(&sub, &a, &b)
-			neon.VabsqF32(&abs, &sub)
-			for l := 0; l < 4; l++ {
-				sum += T(abs[l])
-			}
-		}
+	switch values := s.Data.Values.(type) {
+	case *Int8Matrix[T]:
+		return manhattanInt8(query, values, *i, weights)
+	case [][]Float16:
+		return manhattanFloat16(query, values[*i], weights)
+	}
 
-		for j := n - n%4; j < n; j++ {
-			sum += Abs(query[j] - data[j])
-		}
+	data := s.Data.Row(*i)
 
-		return sum
+	if s.Weights != nil {
+		return s.manhattanWeighted(query, data, s.Weights.Values.([]T))
+	}
+
+	if s.SIMD {
+		return manhattanSIMD(query, data)
 	}
 
 	if len(query) > 128 {
-		return s.manhattanUnrolled(query, data)
+		return manhattanUnrolled(query, data)
 	}
 	var sum T
 	for j := 0; j < len(query); j++ {
@@ -48,7 +37,7 @@ func (s *Search[T]) Manhattan(i *int) T {
 	return sum
 }
 
-func (s *Search[T]) manhattanUnrolled(query, data []T) T {
+func manhattanUnrolled[T float32 | float64](query, data []T) T {
 	var sum T
 	n := len(query)
 
@@ -66,108 +55,503 @@ func (s *Search[T]) manhattanUnrolled(query, data []T) T {
 	return sum
 }
 
-func (s *Search[T]) Einsum() []T {
-	qCols := s.Query.Shape[0]
-	dRows := s.Data.Shape[0]
-	result := make([]T, dRows)
+func (s *Search[T]) manhattanWeighted(query, data, weights []T) T {
+	var sum T
+	n := len(query)
 
-	if s.Multithread {
-		if s.MaxWorkers == 0 {
-			s.MaxWorkers = runtime.NumCPU()
-		}
+	for j := 0; j < n-3; j += 4 {
+		sum += weights[j]*Abs(query[j]-data[j]) +
+			weights[j+1]*Abs(query[j+1]-data[j+1]) +
+			weights[j+2]*Abs(query[j+2]-data[j+2]) +
+			weights[j+3]*Abs(query[j+3]-data[j+3])
+	}
 
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, s.MaxWorkers)
-		var mu sync.Mutex
+	for j := n - n%4; j < n; j++ {
+		sum += weights[j] * Abs(query[j]-data[j])
+	}
 
-		worker := func(s *Search[T], i int, wg *sync.WaitGroup) {
-			defer wg.Done()
-			dot := T(0)
-			for j := 0; j < qCols; j++ {
-				dot += s.Query.Values.([]T)[j] * s.Data.Values.([][]T)[i][j]
-			}
+	return sum
+}
+
+func chebyshev[T float32 | float64](query, data []T) T {
+	if len(query) > 128 {
+		return chebyshevUnrolled(query, data)
+	}
+	var max T
+	for j := 0; j < len(query); j++ {
+		max = maxOf(max, Abs(query[j]-data[j]))
+	}
+	return max
+}
 
-			mu.Lock()
-			result[i] = dot
-			mu.Unlock()
+func chebyshevUnrolled[T float32 | float64](query, data []T) T {
+	var m0, m1, m2, m3 T
+	n := len(query)
 
-			<-sem
-		}
+	for j := 0; j < n-3; j += 4 {
+		m0 = maxOf(m0, Abs(query[j]-data[j]))
+		m1 = maxOf(m1, Abs(query[j+1]-data[j+1]))
+		m2 = maxOf(m2, Abs(query[j+2]-data[j+2]))
+		m3 = maxOf(m3, Abs(query[j+3]-data[j+3]))
+	}
 
-		for i := 0; i < dRows; i++ {
-			sem <- struct{}{}
-			wg.Add(1)
-			go worker(s, i, &wg)
-		}
+	for j := n - n%4; j < n; j++ {
+		m0 = maxOf(m0, Abs(query[j]-data[j]))
+	}
+
+	return maxOf(maxOf(m0, m1), maxOf(m2, m3))
+}
+
+func minkowski[T float32 | float64](query, data []T, p T) T {
+	if len(query) > 128 {
+		return minkowskiUnrolled(query, data, p)
+	}
+	var sum float64
+	for j := 0; j < len(query); j++ {
+		sum += math.Pow(float64(Abs(query[j]-data[j])), float64(p))
+	}
+	return T(math.Pow(sum, 1/float64(p)))
+}
+
+func minkowskiUnrolled[T float32 | float64](query, data []T, p T) T {
+	var sum float64
+	n := len(query)
+	pp := float64(p)
+
+	for j := 0; j < n-3; j += 4 {
+		sum += math.Pow(float64(Abs(query[j]-data[j])), pp) +
+			math.Pow(float64(Abs(query[j+1]-data[j+1])), pp) +
+			math.Pow(float64(Abs(query[j+2]-data[j+2])), pp) +
+			math.Pow(float64(Abs(query[j+3]-data[j+3])), pp)
+	}
+
+	for j := n - n%4; j < n; j++ {
+		sum += math.Pow(float64(Abs(query[j]-data[j])), pp)
+	}
+
+	return T(math.Pow(sum, 1/pp))
+}
+
+// hamming counts the dimensions where query and data differ.
+func hamming[T float32 | float64](query, data []T) T {
+	if len(query) > 128 {
+		return hammingUnrolled(query, data)
+	}
+	var count T
+	for j := 0; j < len(query); j++ {
+		count += ne(query[j], data[j])
+	}
+	return count
+}
+
+func hammingUnrolled[T float32 | float64](query, data []T) T {
+	var count T
+	n := len(query)
+
+	for j := 0; j < n-3; j += 4 {
+		count += ne(query[j], data[j]) +
+			ne(query[j+1], data[j+1]) +
+			ne(query[j+2], data[j+2]) +
+			ne(query[j+3], data[j+3])
+	}
+
+	for j := n - n%4; j < n; j++ {
+		count += ne(query[j], data[j])
+	}
+
+	return count
+}
+
+// jaccard is the weighted Jaccard distance 1 - Σmin/Σmax, for non-negative
+// vectors. Two zero vectors have distance 0.
+func jaccard[T float32 | float64](query, data []T) T {
+	if len(query) > 128 {
+		return jaccardUnrolled(query, data)
+	}
+	var num, den T
+	for j := 0; j < len(query); j++ {
+		num += minOf(query[j], data[j])
+		den += maxOf(query[j], data[j])
+	}
+	return ratio(den-num, den)
+}
+
+func jaccardUnrolled[T float32 | float64](query, data []T) T {
+	var num, den T
+	n := len(query)
+
+	for j := 0; j < n-3; j += 4 {
+		num += minOf(query[j], data[j]) +
+			minOf(query[j+1], data[j+1]) +
+			minOf(query[j+2], data[j+2]) +
+			minOf(query[j+3], data[j+3])
+		den += maxOf(query[j], data[j]) +
+			maxOf(query[j+1], data[j+1]) +
+			maxOf(query[j+2], data[j+2]) +
+			maxOf(query[j+3], data[j+3])
+	}
+
+	for j := n - n%4; j < n; j++ {
+		num += minOf(query[j], data[j])
+		den += maxOf(query[j], data[j])
+	}
+
+	return ratio(den-num, den)
+}
+
+// canberra skips dimensions where both values are 0.
+func canberra[T float32 | float64](query, data []T) T {
+	if len(query) > 128 {
+		return canberraUnrolled(query, data)
+	}
+	var sum T
+	for j := 0; j < len(query); j++ {
+		sum += ratio(Abs(query[j]-data[j]), Abs(query[j])+Abs(data[j]))
+	}
+	return sum
+}
+
+func canberraUnrolled[T float32 | float64](query, data []T) T {
+	var sum T
+	n := len(query)
 
-		wg.Wait()
-		close(sem)
+	for j := 0; j < n-3; j += 4 {
+		sum += ratio(Abs(query[j]-data[j]), Abs(query[j])+Abs(data[j])) +
+			ratio(Abs(query[j+1]-data[j+1]), Abs(query[j+1])+Abs(data[j+1])) +
+			ratio(Abs(query[j+2]-data[j+2]), Abs(query[j+2])+Abs(data[j+2])) +
+			ratio(Abs(query[j+3]-data[j+3]), Abs(query[j+3])+Abs(data[j+3]))
+	}
+
+	for j := n - n%4; j < n; j++ {
+		sum += ratio(Abs(query[j]-data[j]), Abs(query[j])+Abs(data[j]))
+	}
+
+	return sum
+}
 
-		return result
+func braycurtis[T float32 | float64](query, data []T) T {
+	if len(query) > 128 {
+		return braycurtisUnrolled(query, data)
+	}
+	var num, den T
+	for j := 0; j < len(query); j++ {
+		num += Abs(query[j] - data[j])
+		den += Abs(query[j] + data[j])
 	}
+	return ratio(num, den)
+}
+
+func braycurtisUnrolled[T float32 | float64](query, data []T) T {
+	var num, den T
+	n := len(query)
 
-	for i := 0; i < dRows; i++ {
-		dot := T(0)
-		for j := 0; j < qCols; j++ {
-			dot += s.Query.Values.([]T)[j] * s.Data.Values.([][]T)[i][j]
+	for j := 0; j < n-3; j += 4 {
+		num += Abs(query[j]-data[j]) +
+			Abs(query[j+1]-data[j+1]) +
+			Abs(query[j+2]-data[j+2]) +
+			Abs(query[j+3]-data[j+3])
+		den += Abs(query[j]+data[j]) +
+			Abs(query[j+1]+data[j+1]) +
+			Abs(query[j+2]+data[j+2]) +
+			Abs(query[j+3]+data[j+3])
+	}
+
+	for j := n - n%4; j < n; j++ {
+		num += Abs(query[j] - data[j])
+		den += Abs(query[j] + data[j])
+	}
+
+	return ratio(num, den)
+}
+
+func dot[T float32 | float64](query, data []T) T {
+	var sum T
+	n := len(query)
+
+	for j := 0; j < n-3; j += 4 {
+		sum += query[j]*data[j] +
+			query[j+1]*data[j+1] +
+			query[j+2]*data[j+2] +
+			query[j+3]*data[j+3]
+	}
+
+	for j := n - n%4; j < n; j++ {
+		sum += query[j] * data[j]
+	}
+
+	return sum
+}
+
+func squaredEuclidean[T float32 | float64](query, data []T) T {
+	var sum T
+	n := len(query)
+
+	for j := 0; j < n-3; j += 4 {
+		d0 := query[j] - data[j]
+		d1 := query[j+1] - data[j+1]
+		d2 := query[j+2] - data[j+2]
+		d3 := query[j+3] - data[j+3]
+		sum += d0*d0 + d1*d1 + d2*d2 + d3*d3
+	}
+
+	for j := n - n%4; j < n; j++ {
+		d := query[j] - data[j]
+		sum += d * d
+	}
+
+	return sum
+}
+
+func (s *Search[T]) Einsum() []T {
+	rowDot := s.rowDot(s.weighted(s.Query.Values.([]T)))
+	result := make([]T, s.Data.Shape[0])
+
+	// each worker writes its own chunk of result, no lock needed
+	s.chunks(len(result), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if s.keep(i) {
+				result[i] = rowDot(i)
+			}
 		}
-		result[i] = dot
+	})
+
+	return result
+}
+
+const blockSize = 64
+
+// BatchEinsum computes the dot product of every query row against every
+// data row as a blocked matrix-matrix product, result[q][i] = Q[q]·D[i].
+// The batch searches reduce the products as they go instead.
+func (s *Search[T]) BatchEinsum() [][]T {
+	result := make([][]T, s.Query.Shape[0])
+	for q := range result {
+		result[q] = make([]T, s.Data.Shape[0])
 	}
 
+	// each worker writes its own rows of result, no lock needed
+	s.batchDots(1, func(qlo, qhi int) (func(q, i int, dot T), func()) {
+		return func(q, i int, dot T) { result[q][i] = dot }, func() {}
+	})
+
 	return result
 }
 
+// tiles computes the dot products of queries, numbered from q0, against
+// data rows [lo, hi) in blockSize x blockSize tiles, and visits each block
+// of rows once its products are complete.
+func (s *Search[T]) tiles(queries [][]T, q0, lo, hi int, visit func(q, i int, dot T)) {
+	cols := s.Data.Shape[1]
+	tile := make([]T, len(queries)*blockSize)
+
+	for ii := lo; ii < hi; ii += blockSize {
+		iEnd := min(ii+blockSize, hi)
+		clear(tile)
+
+		for jj := 0; jj < cols; jj += blockSize {
+			jEnd := min(jj+blockSize, cols)
+			for q, query := range queries {
+				out := tile[q*blockSize:]
+				for i := ii; i < iEnd; i++ {
+					row := s.Data.Row(i)
+					dot := T(0)
+					for j := jj; j < jEnd; j++ {
+						dot += query[j] * row[j]
+					}
+					out[i-ii] += dot
+				}
+			}
+		}
+
+		for q := range queries {
+			for i := ii; i < iEnd; i++ {
+				if s.keep(i) {
+					visit(q0+q, i, tile[q*blockSize+i-ii])
+				}
+			}
+		}
+	}
+}
+
 func (s *Search[T]) HalfNorm() []T {
-	dRows := s.Data.Shape[0]
-	dCols := s.Data.Shape[1]
-	result := make([]T, dRows)
+	rowNorm := s.rowSquaredNorm()
+	result := make([]T, s.Data.Shape[0])
 
-	if s.Multithread {
-		if s.MaxWorkers == 0 {
-			s.MaxWorkers = runtime.NumCPU()
+	s.chunks(len(result), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			result[i] = rowNorm(i) * T(0.5)
 		}
+	})
 
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, s.MaxWorkers)
-		var mu sync.Mutex
+	return result
+}
 
-		worker := func(s *Search[T], i int, wg *sync.WaitGroup) {
-			defer wg.Done()
-			norm := T(0)
-			for j := 0; j < dCols; j++ {
-				norm += s.Data.Values.([][]T)[i][j] * s.Data.Values.([][]T)[i][j]
+// rowDot returns a function computing query·row(i) directly on the data
+// storage, plain or quantized.
+func (s *Search[T]) rowDot(query []T) func(i int) T {
+	switch values := s.Data.Values.(type) {
+	case *Int8Matrix[T]:
+		// q·(o + s∘c) = q·o + (q∘s)·c
+		var base T
+		scaled := make([]T, len(query))
+		for j := range query {
+			base += query[j] * values.Offset[j]
+			scaled[j] = query[j] * values.Scale[j]
+		}
+		return func(i int) T {
+			dot := base
+			for j, c := range values.Codes[i] {
+				dot += scaled[j] * T(c)
 			}
+			return dot
+		}
+	case [][]Float16:
+		return func(i int) T {
+			dot := T(0)
+			for j, h := range values[i] {
+				dot += query[j] * T(h.Float32())
+			}
+			return dot
+		}
+	}
 
-			mu.Lock()
-			result[i] = norm * T(0.5)
-			mu.Unlock()
+	if s.SIMD {
+		return func(i int) T {
+			return dotSIMD(query, s.Data.Row(i))
+		}
+	}
+	return func(i int) T {
+		return dot(query, s.Data.Row(i))
+	}
+}
 
-			<-sem
+// rowSquaredNorm returns a function computing Σ w·x² of row(i).
+func (s *Search[T]) rowSquaredNorm() func(i int) T {
+	var weights []T
+	if s.Weights != nil {
+		weights = s.Weights.Values.([]T)
+	}
+	weighted := func(norm T, j int, x T) T {
+		if weights != nil {
+			return norm + weights[j]*x*x
 		}
+		return norm + x*x
+	}
 
-		for i := 0; i < dRows; i++ {
-			sem <- struct{}{}
-			wg.Add(1)
-			go worker(s, i, &wg)
+	switch values := s.Data.Values.(type) {
+	case *Int8Matrix[T]:
+		return func(i int) T {
+			norm := T(0)
+			for j := range values.Codes[i] {
+				norm = weighted(norm, j, values.at(i, j))
+			}
+			return norm
+		}
+	case [][]Float16:
+		return func(i int) T {
+			norm := T(0)
+			for j, h := range values[i] {
+				norm = weighted(norm, j, T(h.Float32()))
+			}
+			return norm
 		}
+	}
 
-		wg.Wait()
-		close(sem)
+	return func(i int) T {
+		row := s.Data.Row(i)
+		return s.squaredNorm(row, len(row))
+	}
+}
+
+// squaredNorm is Σ w·x², with w = 1 when no weights are set.
+func (s *Search[T]) squaredNorm(row []T, n int) T {
+	norm := T(0)
+	if s.Weights != nil {
+		weights := s.Weights.Values.([]T)
+		for j := 0; j < n; j++ {
+			norm += weights[j] * row[j] * row[j]
+		}
+		return norm
+	}
+	if s.SIMD {
+		return squaredNormSIMD(row[:n])
+	}
+	for j := 0; j < n; j++ {
+		norm += row[j] * row[j]
+	}
+	return norm
+}
 
-		return result
+// squaredDistance is the exact squared Euclidean distance between query
+// and row i, weighted when Weights is set. It is accumulated in float64,
+// so it doesn't cancel like |x|²/2 - q·x for rows close to the query.
+func (s *Search[T]) squaredDistance(query []T, i int) float64 {
+	var weights []T
+	if s.Weights != nil {
+		weights = s.Weights.Values.([]T)
 	}
 
-	for i := 0; i < dRows; i++ {
-		norm := T(0)
-		for j := 0; j < dCols; j++ {
-			norm += s.Data.Values.([][]T)[i][j] * s.Data.Values.([][]T)[i][j]
+	var at func(j int) T
+	switch values := s.Data.Values.(type) {
+	case *Int8Matrix[T]:
+		at = func(j int) T { return values.at(i, j) }
+	case [][]Float16:
+		at = func(j int) T { return T(values[i][j].Float32()) }
+	default:
+		row := s.Data.Row(i)
+		sum := 0.0
+		for j, q := range query {
+			d := float64(q) - float64(row[j])
+			if weights != nil {
+				sum += float64(weights[j]) * d * d
+				continue
+			}
+			sum += d * d
 		}
-		result[i] = norm * T(0.5)
+		return sum
 	}
 
+	sum := 0.0
+	for j, q := range query {
+		d := float64(q) - float64(at(j))
+		if weights != nil {
+			sum += float64(weights[j]) * d * d
+			continue
+		}
+		sum += d * d
+	}
+	return sum
+}
+
+// weighted returns w∘query, or query itself when no weights are set.
+func (s *Search[T]) weighted(query []T) []T {
+	if s.Weights == nil {
+		return query
+	}
+	weights := s.Weights.Values.([]T)
+	result := make([]T, len(query))
+	for j := range query {
+		result[j] = weights[j] * query[j]
+	}
 	return result
 }
 
+func (s *Search[T]) Norm() []T {
+	result := s.HalfNorm()
+	for i := range result {
+		result[i] = T(math.Sqrt(float64(result[i] * 2)))
+	}
+	return result
+}
+
+func norm[T float32 | float64](v []T) T {
+	var sum T
+	for _, x := range v {
+		sum += x * x
+	}
+	return T(math.Sqrt(float64(sum)))
+}
+
 func (s *Search[T]) EstimateBinSize() int {
 	binSizes := []struct {
 		threshold uint64
@@ -195,3 +579,32 @@ func Abs[T float32 | float64](a T) T {
 	}
 	return a
 }
+
+func minOf[T float32 | float64](a, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxOf[T float32 | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func ne[T float32 | float64](a, b T) T {
+	if a != b {
+		return 1
+	}
+	return 0
+}
+
+// ratio is a / b, or 0 when b is 0.
+func ratio[T float32 | float64](a, b T) T {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
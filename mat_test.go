@@ -1,7 +1,9 @@
 package knn
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"runtime"
 	"testing"
@@ -73,6 +75,147 @@ func TestManhattan(t *testing.T) {
 	}
 }
 
+func TestSIMDKernels(t *testing.T) {
+	t.Run("float32", testSIMDKernels[float32])
+	t.Run("float64", testSIMDKernels[float64])
+}
+
+// testSIMDKernels checks the vector kernels of the current architecture
+// against the scalar ones, for lengths around every vector width.
+func testSIMDKernels[T float32 | float64](t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	near := func(got, want T) bool {
+		return math.Abs(float64(got-want)) <= 1e-4*(1+math.Abs(float64(want)))
+	}
+
+	for n := 0; n <= 70; n++ {
+		a := make([]T, n)
+		b := make([]T, n+3) // longer data rows are cut to the query length
+		for j := range b {
+			b[j] = T(r.NormFloat64())
+			if j < n {
+				a[j] = T(r.NormFloat64())
+			}
+		}
+
+		if got, want := manhattanSIMD(a, b), manhattanUnrolled(a, b[:n]); !near(got, want) {
+			t.Errorf("manhattanSIMD n=%d: got %v, want %v", n, got, want)
+		}
+		if got, want := dotSIMD(a, b), dot(a, b[:n]); !near(got, want) {
+			t.Errorf("dotSIMD n=%d: got %v, want %v", n, got, want)
+		}
+		if got, want := squaredNormSIMD(a), dot(a, a); !near(got, want) {
+			t.Errorf("squaredNormSIMD n=%d: got %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestDistanceKernels(t *testing.T) {
+	naive := map[string]func(a, b []float64) float64{
+		"Chebyshev": func(a, b []float64) float64 {
+			max := 0.0
+			for i := range a {
+				max = math.Max(max, math.Abs(a[i]-b[i]))
+			}
+			return max
+		},
+		"Minkowski": func(a, b []float64) float64 {
+			sum := 0.0
+			for i := range a {
+				sum += math.Pow(math.Abs(a[i]-b[i]), 3)
+			}
+			return math.Pow(sum, 1.0/3)
+		},
+		"Hamming": func(a, b []float64) float64 {
+			count := 0.0
+			for i := range a {
+				if a[i] != b[i] {
+					count++
+				}
+			}
+			return count
+		},
+		"Jaccard": func(a, b []float64) float64 {
+			num, den := 0.0, 0.0
+			for i := range a {
+				num += math.Min(a[i], b[i])
+				den += math.Max(a[i], b[i])
+			}
+			if den == 0 {
+				return 0
+			}
+			return 1 - num/den
+		},
+		"Canberra": func(a, b []float64) float64 {
+			sum := 0.0
+			for i := range a {
+				if d := math.Abs(a[i]) + math.Abs(b[i]); d != 0 {
+					sum += math.Abs(a[i]-b[i]) / d
+				}
+			}
+			return sum
+		},
+		"BrayCurtis": func(a, b []float64) float64 {
+			num, den := 0.0, 0.0
+			for i := range a {
+				num += math.Abs(a[i] - b[i])
+				den += math.Abs(a[i] + b[i])
+			}
+			if den == 0 {
+				return 0
+			}
+			return num / den
+		},
+	}
+
+	kernels := map[string]func(a, b []float32) float32{
+		"Chebyshev":  chebyshev[float32],
+		"Minkowski":  func(a, b []float32) float32 { return minkowski(a, b, 3) },
+		"Hamming":    hamming[float32],
+		"Jaccard":    jaccard[float32],
+		"Canberra":   canberra[float32],
+		"BrayCurtis": braycurtis[float32],
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 3, 8, 131, 300} {
+		a := make([]float32, n)
+		b := make([]float32, n)
+		for i := range a {
+			a[i] = float32(r.Intn(10))
+			b[i] = float32(r.Intn(10))
+			if i%5 == 0 {
+				b[i] = a[i]
+			}
+		}
+		a64 := make([]float64, n)
+		b64 := make([]float64, n)
+		for i := range a {
+			a64[i] = float64(a[i])
+			b64[i] = float64(b[i])
+		}
+
+		for name, kernel := range kernels {
+			t.Run(fmt.Sprintf("%s n=%d", name, n), func(t *testing.T) {
+				expected := float32(naive[name](a64, b64))
+				result := kernel(a, b)
+				if !almostEqual(result, expected, 1e-3*(1+expected)) {
+					t.Errorf("Expected %v, got %v", expected, result)
+				}
+			})
+		}
+	}
+
+	t.Run("Zero vectors", func(t *testing.T) {
+		zero := []float32{0, 0, 0}
+		for name, kernel := range kernels {
+			if result := kernel(zero, zero); result != 0 {
+				t.Errorf("%s: expected 0 for zero vectors, got %v", name, result)
+			}
+		}
+	})
+}
+
 func TestEinsum(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -80,6 +223,7 @@ func TestEinsum(t *testing.T) {
 		query       []float32
 		expected    []float32
 		multithread bool
+		simd        bool
 	}{
 		{
 			name:        "Basic test",
@@ -102,6 +246,14 @@ func TestEinsum(t *testing.T) {
 			expected:    []float32{6, 15, 24},
 			multithread: true,
 		},
+		{
+			name:        "SIMD",
+			data:        [][]float32{{1, 2, 3, 4, 5, 6, 7, 8, 9}, {-1, 0, 1, 0, -1, 0, 1, 0, 2}},
+			query:       []float32{1, 1, 1, 1, 1, 1, 1, 1, 1},
+			expected:    []float32{45, 2},
+			multithread: true,
+			simd:        true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +263,7 @@ func TestEinsum(t *testing.T) {
 				Query:       &Tensor[float32]{Values: tt.query, Shape: [2]int{len(tt.query)}},
 				Multithread: tt.multithread,
 				MaxWorkers:  runtime.NumCPU(),
+				SIMD:        tt.simd,
 			}
 			result := s.Einsum()
 			if !reflect.DeepEqual(result, tt.expected) {
@@ -120,12 +273,52 @@ func TestEinsum(t *testing.T) {
 	}
 }
 
+func TestBatchEinsum(t *testing.T) {
+	data := make([][]float32, 150)
+	for i := range data {
+		data[i] = make([]float32, 70)
+		for j := range data[i] {
+			data[i][j] = float32((i*7+j*3)%11) - 5
+		}
+	}
+	queries := make([][]float32, 70)
+	for i := range queries {
+		queries[i] = make([]float32, 70)
+		for j := range queries[i] {
+			queries[i][j] = float32((i*5+j)%13) - 6
+		}
+	}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        &Tensor[float32]{Values: data, Shape: [2]int{len(data), len(data[0])}},
+			Query:       &Tensor[float32]{Values: queries, Shape: [2]int{len(queries), len(queries[0])}},
+			Multithread: multithread,
+			MaxWorkers:  runtime.NumCPU(),
+		}
+
+		result := s.BatchEinsum()
+		for q := range queries {
+			for i := range data {
+				expected := float32(0)
+				for j := range queries[q] {
+					expected += queries[q][j] * data[i][j]
+				}
+				if result[q][i] != expected {
+					t.Fatalf("multithread=%v: result[%d][%d] = %v, expected %v", multithread, q, i, result[q][i], expected)
+				}
+			}
+		}
+	}
+}
+
 func TestHalfNorm(t *testing.T) {
 	tests := []struct {
 		name        string
 		data        [][]float32
 		expected    []float32
 		multithread bool
+		simd        bool
 	}{
 		{
 			name:        "Basic test",
@@ -162,6 +355,85 @@ func TestHalfNorm(t *testing.T) {
 	}
 }
 
+func TestNorm(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        [][]float32
+		expected    []float32
+		multithread bool
+	}{
+		{
+			name:        "Basic test",
+			data:        [][]float32{{3, 4}, {0, 5}},
+			expected:    []float32{5, 5},
+			multithread: false,
+		},
+		{
+			name:        "Zero vector",
+			data:        [][]float32{{0, 0}, {1, 0}},
+			expected:    []float32{0, 1},
+			multithread: false,
+		},
+		{
+			name:        "Multithreaded",
+			data:        [][]float32{{3, 4}, {6, 8}, {0, 2}},
+			expected:    []float32{5, 10, 2},
+			multithread: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Search[float32]{
+				Data:        &Tensor[float32]{Values: tt.data, Shape: [2]int{len(tt.data), len(tt.data[0])}},
+				Multithread: tt.multithread,
+				MaxWorkers:  runtime.NumCPU(),
+			}
+			result := s.Norm()
+			for i := range result {
+				if !almostEqual(result[i], tt.expected[i], 1e-6) {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	data := [][]float32{{1, 2, 3, 4, 5}, {4, 5, 6, 7, 8}}
+	query := []float32{1, 1, 1, 1, 1}
+	weights := []float32{2, 0, 1, 0.5, 1}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        &Tensor[float32]{Values: data, Shape: [2]int{len(data), len(data[0])}},
+			Query:       &Tensor[float32]{Values: query, Shape: [2]int{len(query)}},
+			Weights:     &Tensor[float32]{Values: weights, Shape: [2]int{len(weights)}, Rank: 1},
+			Multithread: multithread,
+			MaxWorkers:  runtime.NumCPU(),
+		}
+
+		for i := range data {
+			var manhattan, dot, halfnorm float32
+			for j := range query {
+				manhattan += weights[j] * Abs(query[j]-data[i][j])
+				dot += weights[j] * query[j] * data[i][j]
+				halfnorm += weights[j] * data[i][j] * data[i][j] / 2
+			}
+
+			if result := s.Manhattan(&i); result != manhattan {
+				t.Errorf("Manhattan row %d: expected %v, got %v", i, manhattan, result)
+			}
+			if result := s.Einsum()[i]; result != dot {
+				t.Errorf("Einsum row %d: expected %v, got %v", i, dot, result)
+			}
+			if result := s.HalfNorm()[i]; result != halfnorm {
+				t.Errorf("HalfNorm row %d: expected %v, got %v", i, halfnorm, result)
+			}
+		}
+	}
+}
+
 func TestEstimateBinSize(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -188,27 +460,45 @@ func TestEstimateBinSize(t *testing.T) {
 }
 
 func BenchmarkManhattan(b *testing.B) {
-	data := make([][]float32, 1000)
+	b.Run("float32", benchmarkManhattan[float32])
+	b.Run("float64", benchmarkManhattan[float64])
+}
+
+func benchmarkManhattan[T float32 | float64](b *testing.B) {
+	data := make([][]T, 1000)
 	for i := range data {
-		data[i] = make([]float32, 100)
+		data[i] = make([]T, 1024)
 		for j := range data[i] {
-			data[i][j] = float32(i + j)
+			data[i][j] = T(i + j)
 		}
 	}
-	query := make([]float32, 100)
+	query := make([]T, 1024)
 	for i := range query {
-		query[i] = float32(i)
+		query[i] = T(i)
 	}
 
-	s := &Search[float32]{
-		Data:  &Tensor[float32]{Values: data},
-		Query: &Tensor[float32]{Values: query},
+	benchmarks := []struct {
+		name string
+		simd bool
+	}{
+		{"Unrolled", false},
+		{"SIMD", true},
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		index := i % 1000
-		_ = s.Manhattan(&index)
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			s := &Search[T]{
+				Data:  &Tensor[T]{Values: data},
+				Query: &Tensor[T]{Values: query},
+				SIMD:  bm.simd,
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				index := i % 1000
+				_ = s.Manhattan(&index)
+			}
+		})
 	}
 }
 
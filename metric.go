@@ -0,0 +1,46 @@
+package knn
+
+import (
+	"errors"
+)
+
+// Metric is a user supplied distance between two vectors of equal length.
+// Similarity reports whether larger values mean closer vectors, in which
+// case results are ordered highest first.
+type Metric[T float32 | float64] interface {
+	Distance(a, b []T) T
+	Similarity() bool
+}
+
+// DistanceFunc adapts a plain function to a Metric where smaller is better.
+type DistanceFunc[T float32 | float64] func(a, b []T) T
+
+func (f DistanceFunc[T]) Distance(a, b []T) T { return f(a, b) }
+func (f DistanceFunc[T]) Similarity() bool    { return false }
+
+func (s *Search[T]) Custom(k int, metric Metric[T]) (Neighbors[T], error) {
+	if metric == nil {
+		return Neighbors[T]{}, errors.New("metric must not be nil")
+	}
+	if err := s.checker(k); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	query := s.Query.Values.([]T)
+	if !s.Data.dense() {
+		return Neighbors[T]{}, errors.New("quantized data only supports L1, L2, MIPS and Cosine")
+	}
+	if s.Weights != nil {
+		return Neighbors[T]{}, errors.New("weights only support L1, L2, MIPS and Cosine")
+	}
+
+	if metric.Similarity() {
+		return negate(s.scan(k, func(i int) T {
+			return -metric.Distance(query, s.Data.Row(i))
+		}))
+	}
+
+	return s.scan(k, func(i int) T {
+		return metric.Distance(query, s.Data.Row(i))
+	})
+}
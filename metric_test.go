@@ -0,0 +1,96 @@
+package knn
+
+import (
+	"reflect"
+	"testing"
+)
+
+type dotMetric struct{}
+
+func (dotMetric) Distance(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func (dotMetric) Similarity() bool { return true }
+
+func TestCustom(t *testing.T) {
+	data := [][]float32{
+		{1.0, 2.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{7.0, 8.0, 9.0},
+		{10.0, 11.0, 12.0},
+	}
+	query := []float32{3.0, 4.0, 5.0}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+
+	chebyshev := DistanceFunc[float32](func(a, b []float32) float32 {
+		var max float32
+		for i := range a {
+			if d := Abs(a[i] - b[i]); d > max {
+				max = d
+			}
+		}
+		return max
+	})
+
+	tests := []struct {
+		name            string
+		metric          Metric[float32]
+		expectedIndices []int
+		expectedValues  []float32
+	}{
+		{
+			name:            "Distance",
+			metric:          chebyshev,
+			expectedIndices: []int{1, 0},
+			expectedValues:  []float32{1, 2},
+		},
+		{
+			name:            "Similarity",
+			metric:          dotMetric{},
+			expectedIndices: []int{3, 2},
+			expectedValues:  []float32{134, 98},
+		},
+	}
+
+	for _, multithread := range []bool{false, true} {
+		s := &Search[float32]{
+			Data:        dataTensor,
+			Query:       queryTensor,
+			Multithread: multithread,
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				neighbors, err := s.Custom(2, tt.metric)
+				if err != nil {
+					t.Fatalf("Custom search failed: %v", err)
+				}
+				if !reflect.DeepEqual(neighbors.Indices, tt.expectedIndices) {
+					t.Errorf("Indices mismatch. Got %v, want %v", neighbors.Indices, tt.expectedIndices)
+				}
+				if !reflect.DeepEqual(neighbors.Values, tt.expectedValues) {
+					t.Errorf("Values mismatch. Got %v, want %v", neighbors.Values, tt.expectedValues)
+				}
+			})
+		}
+	}
+
+	t.Run("Error Cases", func(t *testing.T) {
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		if _, err := s.Custom(2, nil); err == nil {
+			t.Error("Expected error for nil metric, got nil")
+		}
+		if _, err := s.Custom(0, chebyshev); err == nil {
+			t.Error("Expected error for k=0, got nil")
+		}
+	})
+}
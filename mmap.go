@@ -0,0 +1,177 @@
+package knn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// Raw tensor files start with a 64 byte little-endian header followed by the
+// matrix in row-major order:
+//
+//	offset  size  field
+//	0       4     magic "KNNT"
+//	4       4     version (1)
+//	8       4     element size in bytes (4 = float32, 8 = float64)
+//	12      4     reserved
+//	16      8     rows
+//	24      8     cols
+//	32      32    reserved
+//
+// The header size keeps the data aligned when the file is mapped.
+const (
+	rawMagic      = "KNNT"
+	rawVersion    = 1
+	rawHeaderSize = 64
+)
+
+// ExportMmap writes a matrix in the raw tensor format read by OpenMmap.
+func ExportMmap[T float32 | float64](t *Tensor[T], filename string) error {
+	if t == nil || t.Rank != 2 {
+		return errors.New("tensor must be a matrix")
+	}
+	if !t.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	if !littleEndian() {
+		return errors.New("raw tensors require a little-endian host")
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Close()
+
+	size := int(unsafe.Sizeof(T(0)))
+	var header [rawHeaderSize]byte
+	copy(header[:], rawMagic)
+	binary.LittleEndian.PutUint32(header[4:], rawVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(size))
+	binary.LittleEndian.PutUint64(header[16:], uint64(t.Shape[0]))
+	binary.LittleEndian.PutUint64(header[24:], uint64(t.Shape[1]))
+
+	if _, err := file.Write(header[:]); err != nil {
+		return fmt.Errorf("error writing tensor: %v", err)
+	}
+
+	if flat, stride := t.Flat(); flat != nil && stride == t.Shape[1] {
+		if _, err := file.Write(asBytes(flat)); err != nil {
+			return fmt.Errorf("error writing tensor: %v", err)
+		}
+		return nil
+	}
+
+	for i := 0; i < t.Shape[0]; i++ {
+		if _, err := file.Write(asBytes(t.Row(i))); err != nil {
+			return fmt.Errorf("error writing tensor: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// OpenMmap maps a raw tensor file written by ExportMmap into memory without
+// reading it, so the matrix can be larger than RAM. Pages are loaded by the
+// OS as rows are scanned. Writes to the tensor are private to the process.
+// Call Close to release the mapping.
+func OpenMmap[T float32 | float64](filename string) (*Tensor[T], error) {
+	if !littleEndian() {
+		return nil, errors.New("raw tensors require a little-endian host")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	rows, cols, err := readRawHeader[T](file)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	size := int64(rows) * int64(cols) * int64(unsafe.Sizeof(T(0)))
+	if info.Size() != rawHeaderSize+size {
+		return nil, fmt.Errorf("expected %d bytes of data, got %d", size, info.Size()-rawHeaderSize)
+	}
+
+	mapped, err := mmap(file, int(rawHeaderSize+size))
+	if err != nil {
+		return nil, fmt.Errorf("error mapping file: %v", err)
+	}
+
+	data := mapped[rawHeaderSize:]
+	flat := unsafe.Slice((*T)(unsafe.Pointer(unsafe.SliceData(data))), rows*cols)
+
+	t := &Tensor[T]{}
+	t.setStorage(flat, rows, cols, cols)
+	t.mapped = mapped
+
+	return t, nil
+}
+
+// Close releases the memory of a tensor opened with OpenMmap. The tensor and
+// any rows taken from it must not be used afterwards.
+func (t *Tensor[T]) Close() error {
+	if t.mapped == nil {
+		return nil
+	}
+
+	err := munmap(t.mapped)
+	t.mapped = nil
+	t.Values = nil
+	t.Shape = [2]int{}
+	t.Rank = 0
+	t.flat = nil
+	t.stride = 0
+
+	return err
+}
+
+func readRawHeader[T float32 | float64](r io.Reader) (int, int, error) {
+	var header [rawHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, fmt.Errorf("error reading header: %v", err)
+	}
+
+	if string(header[:4]) != rawMagic {
+		return 0, 0, errors.New("not a raw tensor file")
+	}
+	if v := binary.LittleEndian.Uint32(header[4:]); v != rawVersion {
+		return 0, 0, fmt.Errorf("unsupported version: %d", v)
+	}
+	size := binary.LittleEndian.Uint32(header[8:])
+	if size != uint32(unsafe.Sizeof(T(0))) {
+		return 0, 0, fmt.Errorf("element size %d does not match %T", size, T(0))
+	}
+
+	rows := binary.LittleEndian.Uint64(header[16:])
+	cols := binary.LittleEndian.Uint64(header[24:])
+	if rows < 1 || cols < 1 || rows > uint64(maxInt-rawHeaderSize)/cols/uint64(size) {
+		return 0, 0, fmt.Errorf("invalid shape: [%d %d]", rows, cols)
+	}
+
+	return int(rows), int(cols), nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func asBytes[T float32 | float64](v []T) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(v))), len(v)*int(unsafe.Sizeof(T(0))))
+}
+
+func littleEndian() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}
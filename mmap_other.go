@@ -0,0 +1,23 @@
+//go:build !unix
+
+package knn
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// No mmap on this platform, the file is read into an 8 byte aligned buffer.
+func mmap(file *os.File, size int) ([]byte, error) {
+	words := make([]uint64, (size+7)/8)
+	b := unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(words))), size)
+	if _, err := file.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b, nil
+}
+
+func munmap(b []byte) error {
+	return nil
+}
@@ -0,0 +1,191 @@
+package knn
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMmap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rows := make([][]float32, 200)
+	for i := range rows {
+		rows[i] = make([]float32, 16)
+		for j := range rows[i] {
+			rows[i][j] = r.Float32()
+		}
+	}
+
+	data := &Tensor[float32]{}
+	if err := data.New(rows); err != nil {
+		t.Fatal(err)
+	}
+	query := &Tensor[float32]{}
+	if err := query.New(rows[7]); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "data.knnt")
+	if err := ExportMmap(data, filename); err != nil {
+		t.Fatalf("ExportMmap() error = %v", err)
+	}
+
+	mapped, err := OpenMmap[float32](filename)
+	if err != nil {
+		t.Fatalf("OpenMmap() error = %v", err)
+	}
+	defer mapped.Close()
+
+	if mapped.Shape != data.Shape || mapped.Rank != 2 || mapped.Type != data.Type {
+		t.Fatalf("OpenMmap() Shape = %v, Rank = %d, Type = %v", mapped.Shape, mapped.Rank, mapped.Type)
+	}
+	if mapped.Values != nil {
+		t.Errorf("OpenMmap() built Values %T, rows should only be read with Row", mapped.Values)
+	}
+	for i, row := range rows {
+		if !reflect.DeepEqual(mapped.Row(i), row) {
+			t.Fatalf("Row(%d) = %v, want %v", i, mapped.Row(i), row)
+		}
+	}
+
+	for _, metric := range []int{L1, L2, MIPS, Cosine, Chebyshev, Mahalanobis} {
+		want, err := (&Search[float32]{Data: data, Query: query}).metric(5, metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := (&Search[float32]{Data: mapped, Query: query, Multithread: true}).metric(5, metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Indices, want.Indices) {
+			t.Errorf("metric %d: got %v, want %v", metric, got.Indices, want.Indices)
+		}
+	}
+
+	batch := &Tensor[float32]{}
+	_ = batch.New(rows[:3])
+	want, err := (&Search[float32]{Data: data, Query: batch}).BatchL2(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&Search[float32]{Data: mapped, Query: batch}).BatchL2(5)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("BatchL2 on mapped data = %v, %v, want %v", got, err, want)
+	}
+
+	idx := &Index[float32]{}
+	if err := idx.New(mapped); err != nil {
+		t.Fatal(err)
+	}
+	g := &HNSW[float32]{Seed: 1}
+	if err := g.New(mapped); err != nil {
+		t.Fatal(err)
+	}
+	if nn, err := g.Search(query, 1); err != nil || nn.Indices[0] != 7 {
+		t.Errorf("HNSW on mapped data = %v, %v", nn, err)
+	}
+
+	if _, err := mapped.Quantize(QuantInt8); err != nil {
+		t.Errorf("Quantize() on mapped data: %v", err)
+	}
+	packed, err := mapped.Binarize(0.5)
+	if err != nil {
+		t.Errorf("Binarize() on mapped data: %v", err)
+	} else if want, _ := data.Binarize(0.5); !reflect.DeepEqual(packed.Values, want.Values) {
+		t.Errorf("Binarize() on mapped data = %v, want %v", packed.Values, want.Values)
+	}
+	gobFile := filepath.Join(t.TempDir(), "data.tensor")
+	if err := Export(mapped, gobFile); err != nil {
+		t.Fatal(err)
+	}
+	if imported, err := Import[float32](gobFile); err != nil || !reflect.DeepEqual(imported.Values, data.Values) {
+		t.Errorf("Export() of mapped data round trip failed: %v", err)
+	}
+
+	// writes are private to the process
+	mapped.Row(0)[0] = 42
+	again, err := OpenMmap[float32](filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Row(0)[0] != rows[0][0] {
+		t.Errorf("write reached the file: %v", again.Row(0)[0])
+	}
+	if err := again.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if again.Values != nil || again.Rank != 0 {
+		t.Error("Close() did not reset the tensor")
+	}
+}
+
+func TestMmapErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	data := &Tensor[float32]{}
+	if err := data.New([]float32{1, 2, 3, 4, 5, 6}, 3, 2); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(dir, "valid.knnt")
+	if err := ExportMmap(data, valid); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name string, b []byte) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	badMagic := append([]byte{}, buf...)
+	copy(badMagic, "GOB!")
+	badVersion := append([]byte{}, buf...)
+	badVersion[4] = 9
+	badShape := append([]byte{}, buf...)
+	badShape[16] = 0
+
+	tests := []struct {
+		name     string
+		filename string
+	}{
+		{"Missing file", filepath.Join(dir, "missing.knnt")},
+		{"Short header", write("short.knnt", buf[:10])},
+		{"Bad magic", write("magic.knnt", badMagic)},
+		{"Bad version", write("version.knnt", badVersion)},
+		{"Zero rows", write("shape.knnt", badShape)},
+		{"Truncated data", write("truncated.knnt", buf[:len(buf)-4])},
+		{"Trailing data", write("trailing.knnt", append(append([]byte{}, buf...), 0))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenMmap[float32](tt.filename); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		if _, err := OpenMmap[float64](valid); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("Vector", func(t *testing.T) {
+		v := &Tensor[float32]{}
+		if err := v.New([]float32{1, 2}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ExportMmap(v, filepath.Join(dir, "vector.knnt")); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
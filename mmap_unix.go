@@ -0,0 +1,17 @@
+//go:build unix
+
+package knn
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of a file copy-on-write.
+func mmap(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+func munmap(b []byte) error {
+	return syscall.Munmap(b)
+}
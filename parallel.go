@@ -0,0 +1,175 @@
+package knn
+
+import (
+	"runtime"
+	"sync"
+)
+
+// workers is the number of goroutines used for n rows, 1 without
+// Multithread. MaxWorkers defaults to the number of CPUs.
+func (s *Search[T]) workers(n int) int {
+	if !s.Multithread {
+		return 1
+	}
+	workers := s.MaxWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return max(1, min(workers, n))
+}
+
+// chunks splits rows [0, n) into contiguous ranges, one per worker, and
+// runs fn on each range concurrently. A single range runs on the caller.
+func (s *Search[T]) chunks(n int, fn func(lo, hi int)) {
+	workers := s.workers(n)
+	if workers == 1 {
+		fn(0, n)
+		return
+	}
+
+	size := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += size {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, min(n, lo+size))
+	}
+	wg.Wait()
+}
+
+// scan keeps the k rows with the smallest distance(i). Every worker keeps a
+// local top-k heap over its chunk, and the heaps are merged at the end.
+// Results are ordered by distance, then index, so they don't depend on the
+// number of workers.
+func (s *Search[T]) scan(k int, distance func(i int) T) (Neighbors[T], error) {
+	var mu sync.Mutex
+	var results Results[T]
+
+	s.chunks(s.Data.Shape[0], func(lo, hi int) {
+		h := &MaxHeap[T]{}
+		for i := lo; i < hi; i++ {
+			if !s.keep(i) {
+				continue
+			}
+			d := distance(i)
+			h.Process(&i, &k, &d)
+		}
+
+		mu.Lock()
+		results = append(results, h.results...)
+		mu.Unlock()
+	})
+
+	return results.Neighbors(k, false), nil
+}
+
+// within collects every row for which match(i) returns true, with its
+// value. Every worker collects its own chunk and the chunks are merged at
+// the end, so callers sort with Results.Neighbors.
+func (s *Search[T]) within(match func(i int) (T, bool)) Results[T] {
+	var mu sync.Mutex
+	var results Results[T]
+
+	s.chunks(s.Data.Shape[0], func(lo, hi int) {
+		local := Results[T]{}
+		for i := lo; i < hi; i++ {
+			if !s.keep(i) {
+				continue
+			}
+			if v, ok := match(i); ok {
+				local.Process(&i, &v)
+			}
+		}
+
+		mu.Lock()
+		results = append(results, local...)
+		mu.Unlock()
+	})
+
+	return results
+}
+
+// batchDots computes Q[q]·D[i] for every query and kept data row, one block
+// of blockSize queries at a time. The data rows of a block are split across
+// workers in ranges aligned to multiples of align. worker(qlo, qhi) is
+// called once per range and returns visit, which gets the rows of each
+// query in increasing order, and done, which runs under a lock when the
+// range is finished.
+func (s *Search[T]) batchDots(align int, worker func(qlo, qhi int) (visit func(q, i int, dot T), done func())) {
+	queries := s.Query.rows()
+	if s.Weights != nil {
+		weighted := make([][]T, len(queries))
+		for q := range queries {
+			weighted[q] = s.weighted(queries[q])
+		}
+		queries = weighted
+	}
+
+	dense := s.Data.dense()
+	n := s.Data.Shape[0]
+	units := (n + align - 1) / align
+
+	var mu sync.Mutex
+	for qlo := 0; qlo < len(queries); qlo += blockSize {
+		qhi := min(qlo+blockSize, len(queries))
+
+		var rowDots []func(i int) T
+		if !dense {
+			// quantized data, one dot function per query
+			rowDots = make([]func(i int) T, qhi-qlo)
+			for q := range rowDots {
+				rowDots[q] = s.rowDot(queries[qlo+q])
+			}
+		}
+
+		s.chunks(units, func(ulo, uhi int) {
+			visit, done := worker(qlo, qhi)
+			lo, hi := ulo*align, min(uhi*align, n)
+
+			if dense {
+				s.tiles(queries[qlo:qhi], qlo, lo, hi, visit)
+			} else {
+				for q, rowDot := range rowDots {
+					for i := lo; i < hi; i++ {
+						if s.keep(i) {
+							visit(qlo+q, i, rowDot(i))
+						}
+					}
+				}
+			}
+
+			mu.Lock()
+			done()
+			mu.Unlock()
+		})
+	}
+}
+
+// batchScan keeps the k rows with the smallest distance(q, i, dot) for
+// every query. Every worker keeps local top-k heaps for the queries of the
+// current block, and the heaps are merged like in scan.
+func (s *Search[T]) batchScan(k int, distance func(q, i int, dot T) T) []Neighbors[T] {
+	results := make([]Results[T], s.Query.Shape[0])
+
+	s.batchDots(1, func(qlo, qhi int) (func(q, i int, dot T), func()) {
+		heaps := make([]MaxHeap[T], qhi-qlo)
+		visit := func(q, i int, dot T) {
+			d := distance(q, i, dot)
+			heaps[q-qlo].Process(&i, &k, &d)
+		}
+		done := func() {
+			for q := range heaps {
+				results[qlo+q] = append(results[qlo+q], heaps[q].results...)
+			}
+		}
+		return visit, done
+	})
+
+	nns := make([]Neighbors[T], len(results))
+	for q, r := range results {
+		nns[q] = r.Neighbors(k, false)
+	}
+	return nns
+}
@@ -0,0 +1,198 @@
+package knn
+
+import (
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		multithread bool
+		maxWorkers  int
+		workers     int
+	}{
+		{"Single threaded", 100, false, 8, 1},
+		{"Default workers", 100, true, 0, min(runtime.NumCPU(), 100)},
+		{"More workers than rows", 3, true, 8, 3},
+		{"Uneven chunks", 10, true, 4, 4},
+		{"Empty", 0, true, 4, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Search[float32]{Multithread: tt.multithread, MaxWorkers: tt.maxWorkers}
+			if got := s.workers(tt.n); got != tt.workers {
+				t.Errorf("workers(%d) = %d, want %d", tt.n, got, tt.workers)
+			}
+
+			var mu sync.Mutex
+			visits := make([]int, tt.n)
+			calls := 0
+			s.chunks(tt.n, func(lo, hi int) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+				for i := lo; i < hi; i++ {
+					visits[i]++
+				}
+			})
+
+			for i, v := range visits {
+				if v != 1 {
+					t.Errorf("Row %d visited %d times", i, v)
+				}
+			}
+			if calls > tt.workers {
+				t.Errorf("Got %d chunks for %d workers", calls, tt.workers)
+			}
+			if s.MaxWorkers != tt.maxWorkers {
+				t.Errorf("MaxWorkers changed to %d", s.MaxWorkers)
+			}
+		})
+	}
+}
+
+func TestScanWorkers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([][]float32, 500)
+	for i := range data {
+		// few distinct rows, so many distances tie
+		data[i] = []float32{float32(r.Intn(4)), float32(r.Intn(4)), float32(r.Intn(4))}
+	}
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New([]float32{1, 2, 1})
+
+	filter := func(i int) bool { return i%5 != 0 }
+
+	for _, metric := range []int{L1, L2, Cosine, Chebyshev} {
+		single := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter}
+		want, err := single.metric(20, metric)
+		if err != nil {
+			t.Fatalf("Metric %d failed: %v", metric, err)
+		}
+
+		for _, workers := range []int{2, 3, 7, 64, 1000} {
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter, Multithread: true, MaxWorkers: workers}
+			got, err := s.metric(20, metric)
+			if err != nil {
+				t.Fatalf("Metric %d with %d workers failed: %v", metric, workers, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Metric %d with %d workers: got %v, want %v", metric, workers, got.Indices, want.Indices)
+			}
+		}
+
+		for i := 1; i < len(want.Indices); i++ {
+			if want.Values[i] == want.Values[i-1] && want.Indices[i] < want.Indices[i-1] {
+				t.Errorf("Metric %d ties not ordered by index: %v", metric, want.Indices)
+			}
+		}
+	}
+}
+
+func TestRangeWorkers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(randomMatrix(r, 500, 4))
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New([]float32{0.5, 0.5, 0.5, 0.5})
+
+	filter := func(i int) bool { return i%5 != 0 }
+	ranges := map[string]func(s *Search[float32]) (Neighbors[float32], error){
+		"RangeL1":   func(s *Search[float32]) (Neighbors[float32], error) { return s.RangeL1(0.8) },
+		"RangeL2":   func(s *Search[float32]) (Neighbors[float32], error) { return s.RangeL2(0.4) },
+		"RangeMIPS": func(s *Search[float32]) (Neighbors[float32], error) { return s.RangeMIPS(1.2, 30) },
+	}
+
+	for name, search := range ranges {
+		want, err := search(&Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter})
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		if len(want.Indices) == 0 {
+			t.Fatalf("%s found no rows", name)
+		}
+
+		for _, workers := range []int{2, 7, 1000} {
+			s := &Search[float32]{Data: dataTensor, Query: queryTensor, Filter: filter, Multithread: true, MaxWorkers: workers}
+			got, err := search(s)
+			if err != nil {
+				t.Fatalf("%s with %d workers failed: %v", name, workers, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s with %d workers: got %v, want %v", name, workers, got.Indices, want.Indices)
+			}
+		}
+	}
+}
+
+func TestBatchWorkers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ints := func(rows, cols int) [][]float32 {
+		m := make([][]float32, rows)
+		for i := range m {
+			m[i] = make([]float32, cols)
+			for j := range m[i] {
+				// small integers keep every dot product exact
+				m[i][j] = float32(r.Intn(5) - 2)
+			}
+		}
+		return m
+	}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(ints(300, 70))
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(ints(150, 70))
+	quantized, err := dataTensor.Quantize(QuantInt8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter := func(i int) bool { return i%7 != 3 }
+	batches := map[string]func(s *Search[float32]) ([]Neighbors[float32], error){
+		"L2":     func(s *Search[float32]) ([]Neighbors[float32], error) { return s.BatchL2(10) },
+		"MIPS":   func(s *Search[float32]) ([]Neighbors[float32], error) { return s.BatchMIPS(10, 4) },
+		"Cosine": func(s *Search[float32]) ([]Neighbors[float32], error) { return s.BatchCosine(10) },
+	}
+	singles := map[string]func(s *Search[float32]) (Neighbors[float32], error){
+		"L2":     func(s *Search[float32]) (Neighbors[float32], error) { return s.L2(10) },
+		"MIPS":   func(s *Search[float32]) (Neighbors[float32], error) { return s.MIPS(10, 4) },
+		"Cosine": func(s *Search[float32]) (Neighbors[float32], error) { return s.Cosine(10) },
+	}
+
+	for _, data := range []*Tensor[float32]{dataTensor, quantized} {
+		for name, batch := range batches {
+			base := &Search[float32]{Data: data, Query: queryTensor, Filter: filter}
+			want := make([]Neighbors[float32], queryTensor.Shape[0])
+			for q := range want {
+				nn, err := singles[name](base.single(q))
+				if err != nil {
+					t.Fatalf("%s failed: %v", name, err)
+				}
+				want[q] = nn
+			}
+
+			for _, workers := range []int{1, 2, 7, 1000} {
+				s := &Search[float32]{Data: data, Query: queryTensor, Filter: filter, Multithread: true, MaxWorkers: workers}
+				got, err := batch(s)
+				if err != nil {
+					t.Fatalf("Batch %s with %d workers failed: %v", name, workers, err)
+				}
+				for q := range want {
+					if !reflect.DeepEqual(got[q].Indices, want[q].Indices) {
+						t.Errorf("Batch %s with %d workers, query %d: got %v, want %v", name, workers, q, got[q].Indices, want[q].Indices)
+						break
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package knn
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// PQ is a product quantizer. Vectors are split into M sub-spaces and every
+// sub-vector is replaced by the byte index of its nearest codebook entry,
+// so a row is stored in M bytes. Search uses asymmetric distance tables.
+type PQ[T float32 | float64] struct {
+	M          int   // number of sub-spaces, must divide the data dimensions
+	Iterations int   // k-means iterations per sub-space (default = 20)
+	Metric     int   // L2 or MIPS
+	Rerank     int   // if > 0, re-rank this many candidates against the original rows
+	Seed       int64 // seed for k-means
+
+	codebooks [][][]T // [M][ksub][dsub]
+	codes     []byte  // row i is codes[i*M : (i+1)*M]
+	data      *Tensor[T]
+	dim       int
+	dsub      int
+}
+
+func (pq *PQ[T]) New(data *Tensor[T]) error {
+	if data == nil || data.Rank != 2 {
+		return errors.New("data must be a matrix")
+	}
+	if pq.Metric != L2 && pq.Metric != MIPS {
+		return fmt.Errorf("unsupported metric: %d", pq.Metric)
+	}
+	if pq.M <= 0 || data.Shape[1]%pq.M != 0 {
+		return errors.New("number of sub-spaces must divide the data dimensions")
+	}
+	if pq.Iterations <= 0 {
+		pq.Iterations = 20
+	}
+
+	if !data.dense() {
+		return errors.New("quantized data is not supported")
+	}
+	rows := data.rows()
+	pq.dim = data.Shape[1]
+	pq.dsub = pq.dim / pq.M
+	ksub := min(256, len(rows))
+	rng := rand.New(rand.NewSource(pq.Seed))
+
+	pq.codebooks = make([][][]T, pq.M)
+	pq.codes = make([]byte, len(rows)*pq.M)
+
+	for m := 0; m < pq.M; m++ {
+		sub := make([][]T, len(rows))
+		for i, row := range rows {
+			sub[i] = row[m*pq.dsub : (m+1)*pq.dsub]
+		}
+
+		centroids, assign, err := kmeans(sub, ksub, pq.Iterations, rng)
+		if err != nil {
+			return err
+		}
+
+		pq.codebooks[m] = centroids
+		for i, c := range assign {
+			pq.codes[i*pq.M+m] = byte(c)
+		}
+	}
+
+	pq.data = nil
+	if pq.Rerank > 0 {
+		pq.data = data
+	}
+
+	return nil
+}
+
+// Decode reconstructs the approximate vector of row i.
+func (pq *PQ[T]) Decode(i int) []T {
+	vector := make([]T, 0, pq.dim)
+	for m, c := range pq.codes[i*pq.M : (i+1)*pq.M] {
+		vector = append(vector, pq.codebooks[m][c]...)
+	}
+	return vector
+}
+
+// Search returns the approximate k nearest rows. L2 values are Euclidean
+// distances, nearest first, and MIPS values are inner products, highest
+// first.
+func (pq *PQ[T]) Search(query *Tensor[T], k int) (Neighbors[T], error) {
+	if pq.codebooks == nil {
+		return Neighbors[T]{}, errors.New("index must be initialized with New")
+	}
+	if query == nil || query.Rank != 1 {
+		return Neighbors[T]{}, errors.New("query must be a vector")
+	}
+	if query.Shape[0] != pq.dim {
+		return Neighbors[T]{}, errors.New("data and query dimensions do not match")
+	}
+	n := len(pq.codes) / pq.M
+	if k <= 0 || k > n {
+		return Neighbors[T]{}, errors.New("k must be greater than 0 and less than the length of the data")
+	}
+
+	vector := query.Values.([]T)
+	table := pq.table(vector)
+
+	candidates := k
+	if pq.data != nil {
+		candidates = min(max(k, pq.Rerank), n)
+	}
+
+	// similarities are negated so the MaxHeap keeps the largest
+	sign := T(1)
+	if pq.Metric == MIPS {
+		sign = -1
+	}
+
+	h := &MaxHeap[T]{}
+	heap.Init(h)
+	for i := 0; i < n; i++ {
+		var distance T
+		for m, c := range pq.codes[i*pq.M : (i+1)*pq.M] {
+			distance += table[m][c]
+		}
+		distance *= sign
+		h.Process(&i, &candidates, &distance)
+	}
+
+	if pq.data != nil {
+		exact := &MaxHeap[T]{}
+		heap.Init(exact)
+		for h.Len() > 0 {
+			r := heap.Pop(h).(Result[T])
+			distance := squaredEuclidean(vector, pq.data.Row(r.Index))
+			if pq.Metric == MIPS {
+				distance = -dot(vector, pq.data.Row(r.Index))
+			}
+			exact.Process(&r.Index, &k, &distance)
+		}
+		h = exact
+	}
+
+	for h.Len() > k {
+		heap.Pop(h)
+	}
+
+	nn, err := (&Search[T]{}).ret(&k, h)
+	for i, v := range nn.Values {
+		if pq.Metric == MIPS {
+			nn.Values[i] = -v
+		} else {
+			nn.Values[i] = T(math.Sqrt(math.Max(0, float64(v))))
+		}
+	}
+
+	return nn, err
+}
+
+// table holds the distance (or inner product) between every sub-vector of
+// the query and every codebook entry.
+func (pq *PQ[T]) table(query []T) [][]T {
+	table := make([][]T, pq.M)
+	for m, codebook := range pq.codebooks {
+		sub := query[m*pq.dsub : (m+1)*pq.dsub]
+		table[m] = make([]T, len(codebook))
+		for c, centroid := range codebook {
+			if pq.Metric == MIPS {
+				table[m][c] = dot(sub, centroid)
+			} else {
+				table[m][c] = squaredEuclidean(sub, centroid)
+			}
+		}
+	}
+	return table
+}
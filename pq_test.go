@@ -0,0 +1,123 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPQ(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 1000, 16)
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	queries := randomMatrix(r, 20, 16)
+
+	t.Run("Asymmetric distances", func(t *testing.T) {
+		pq := &PQ[float32]{M: 4, Metric: L2, Seed: 1}
+		if err := pq.New(dataTensor); err != nil {
+			t.Fatalf("Failed to train PQ: %v", err)
+		}
+		if len(pq.codes) != len(data)*4 {
+			t.Errorf("Expected %d bytes of codes, got %d", len(data)*4, len(pq.codes))
+		}
+
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+		nn, err := pq.Search(queryTensor, 10)
+		if err != nil {
+			t.Fatalf("PQ search failed: %v", err)
+		}
+
+		// the distance is exact against the reconstructed vector
+		for i, index := range nn.Indices {
+			want := math.Sqrt(float64(squaredEuclidean(queries[0], pq.Decode(index))))
+			if math.Abs(float64(nn.Values[i])-want) > 1e-4 {
+				t.Errorf("Value at %d mismatch. Got %f, want %f", i, nn.Values[i], want)
+			}
+		}
+	})
+
+	t.Run("Rerank", func(t *testing.T) {
+		for _, metric := range []int{L2, MIPS} {
+			pq := &PQ[float32]{M: 8, Metric: metric, Rerank: 100, Seed: 1}
+			if err := pq.New(dataTensor); err != nil {
+				t.Fatalf("Failed to train PQ: %v", err)
+			}
+
+			total := 0.0
+			for _, q := range queries {
+				queryTensor := &Tensor[float32]{}
+				_ = queryTensor.New(q)
+
+				got, err := pq.Search(queryTensor, 5)
+				if err != nil {
+					t.Fatalf("PQ search failed: %v", err)
+				}
+
+				s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+				want, _ := s.metric(5, metric)
+				hits := 0
+				for _, i := range want.Indices {
+					for _, j := range got.Indices {
+						if i == j {
+							hits++
+						}
+					}
+				}
+				total += float64(hits) / 5
+
+				for i, index := range got.Indices {
+					expected := math.Sqrt(float64(squaredEuclidean(q, data[index])))
+					if metric == MIPS {
+						expected = float64(dot(q, data[index]))
+					}
+					if math.Abs(float64(got.Values[i])-expected) > 1e-4 {
+						t.Errorf("Metric %d value at %d is not exact. Got %f, want %f", metric, i, got.Values[i], expected)
+					}
+				}
+			}
+
+			if avg := total / float64(len(queries)); avg < 0.9 {
+				t.Errorf("Metric %d: expected recall of at least 0.9, got %f", metric, avg)
+			}
+		}
+	})
+
+	t.Run("Error Cases", func(t *testing.T) {
+		pq := &PQ[float32]{M: 3, Metric: L2}
+		if err := pq.New(dataTensor); err == nil {
+			t.Error("Expected error for M not dividing the dimensions, got nil")
+		}
+
+		pq = &PQ[float32]{M: 4, Metric: L1}
+		if err := pq.New(dataTensor); err == nil {
+			t.Error("Expected error for unsupported metric, got nil")
+		}
+
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(queries[0])
+		if _, err := pq.Search(queryTensor, 1); err == nil {
+			t.Error("Expected error for untrained index, got nil")
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		small := [][]float32{{1, 2, 3, 4}, {5, 6, 7, 8}}
+		smallTensor := &Tensor[float32]{}
+		_ = smallTensor.New(small)
+
+		pq := &PQ[float32]{M: 2, Metric: L2}
+		if err := pq.New(smallTensor); err != nil {
+			t.Fatalf("Failed to train PQ: %v", err)
+		}
+		for i := range small {
+			if got := pq.Decode(i); !reflect.DeepEqual(got, small[i]) {
+				t.Errorf("Decode(%d) = %v, want %v", i, got, small[i])
+			}
+		}
+	})
+}
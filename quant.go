@@ -0,0 +1,183 @@
+package knn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	QuantInt8 = iota
+	QuantFloat16
+)
+
+// Float16 is an IEEE 754 half precision float.
+type Float16 uint16
+
+// Int8Matrix stores row i, dimension j as Offset[j] + Scale[j]*Codes[i][j].
+type Int8Matrix[T float32 | float64] struct {
+	Codes  [][]int8
+	Scale  []T
+	Offset []T
+}
+
+// Quantize returns a copy of a matrix stored as int8 or float16. Search
+// kernels read the quantized rows directly and report results in T.
+func (t *Tensor[T]) Quantize(format int) (*Tensor[T], error) {
+	if t.Rank != 2 || !t.dense() {
+		return nil, errors.New("only matrices can be quantized")
+	}
+	rows := t.rows()
+
+	q := &Tensor[T]{Shape: t.Shape, Rank: 2}
+
+	switch format {
+	case QuantInt8:
+		q.Values = quantizeInt8(rows)
+		q.Type = reflect.TypeOf(int8(0))
+	case QuantFloat16:
+		values := make([][]Float16, len(rows))
+		for i, row := range rows {
+			values[i] = make([]Float16, len(row))
+			for j, x := range row {
+				values[i][j] = ToFloat16(float32(x))
+			}
+		}
+		q.Values = values
+		q.Type = reflect.TypeOf(Float16(0))
+	default:
+		return nil, fmt.Errorf("unsupported quantization: %d", format)
+	}
+
+	return q, nil
+}
+
+// quantizeInt8 maps every dimension's [min, max] range onto [-127, 127].
+func quantizeInt8[T float32 | float64](rows [][]T) *Int8Matrix[T] {
+	dim := len(rows[0])
+	lo := append([]T{}, rows[0]...)
+	hi := append([]T{}, rows[0]...)
+	for _, row := range rows {
+		for j, x := range row {
+			lo[j] = minOf(lo[j], x)
+			hi[j] = maxOf(hi[j], x)
+		}
+	}
+
+	m := &Int8Matrix[T]{
+		Codes:  make([][]int8, len(rows)),
+		Scale:  make([]T, dim),
+		Offset: make([]T, dim),
+	}
+	for j := 0; j < dim; j++ {
+		m.Offset[j] = (hi[j] + lo[j]) / 2
+		m.Scale[j] = (hi[j] - lo[j]) / 254
+	}
+
+	for i, row := range rows {
+		m.Codes[i] = make([]int8, dim)
+		for j, x := range row {
+			if m.Scale[j] == 0 {
+				continue
+			}
+			c := math.Round(float64((x - m.Offset[j]) / m.Scale[j]))
+			m.Codes[i][j] = int8(math.Max(-127, math.Min(127, c)))
+		}
+	}
+
+	return m
+}
+
+func (m *Int8Matrix[T]) at(i, j int) T {
+	return m.Offset[j] + m.Scale[j]*T(m.Codes[i][j])
+}
+
+func ToFloat16(f float32) Float16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int((b>>23)&0xff) - 127 + 15
+	mant := b & 0x7fffff
+
+	if (b>>23)&0xff == 0xff {
+		if mant != 0 {
+			return Float16(sign | 0x7e00)
+		}
+		return Float16(sign | 0x7c00)
+	}
+	if exp >= 0x1f {
+		return Float16(sign | 0x7c00)
+	}
+
+	if exp <= 0 {
+		// subnormal
+		if exp < -10 {
+			return Float16(sign)
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		mid := uint32(1) << (shift - 1)
+		if rem > mid || (rem == mid && half&1 == 1) {
+			half++
+		}
+		return Float16(sign | uint16(half))
+	}
+
+	// round to nearest even, a carry into the exponent is still correct
+	half := uint32(exp)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return Float16(sign | uint16(half))
+}
+
+func (h Float16) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		e := uint32(127 - 14)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+func manhattanInt8[T float32 | float64](query []T, m *Int8Matrix[T], i int, weights []T) T {
+	var sum T
+	codes := m.Codes[i]
+	for j, c := range codes {
+		d := Abs(query[j] - (m.Offset[j] + m.Scale[j]*T(c)))
+		if weights != nil {
+			d *= weights[j]
+		}
+		sum += d
+	}
+	return sum
+}
+
+func manhattanFloat16[T float32 | float64](query []T, row []Float16, weights []T) T {
+	var sum T
+	for j, h := range row {
+		d := Abs(query[j] - T(h.Float32()))
+		if weights != nil {
+			d *= weights[j]
+		}
+		sum += d
+	}
+	return sum
+}
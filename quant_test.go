@@ -0,0 +1,147 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFloat16(t *testing.T) {
+	tests := []struct {
+		name  string
+		input float32
+		bits  Float16
+	}{
+		{"One", 1.0, 0x3c00},
+		{"Negative", -2.0, 0xc000},
+		{"Zero", 0.0, 0x0000},
+		{"Max", 65504.0, 0x7bff},
+		{"Overflow", 1e6, 0x7c00},
+		{"Smallest subnormal", float32(math.Pow(2, -24)), 0x0001},
+		{"Smallest normal", float32(math.Pow(2, -14)), 0x0400},
+		{"Rounded", 0.1, 0x2e66},
+		{"Infinity", float32(math.Inf(-1)), 0xfc00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToFloat16(tt.input); got != tt.bits {
+				t.Errorf("ToFloat16(%v) = %#04x, want %#04x", tt.input, uint16(got), uint16(tt.bits))
+			}
+		})
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		for bits := 0; bits < 0x7c00; bits++ {
+			h := Float16(bits)
+			if got := ToFloat16(h.Float32()); got != h {
+				t.Fatalf("Round trip of %#04x gave %#04x", bits, uint16(got))
+			}
+		}
+	})
+
+	t.Run("NaN", func(t *testing.T) {
+		if f := ToFloat16(float32(math.NaN())).Float32(); !math.IsNaN(float64(f)) {
+			t.Errorf("Expected NaN, got %v", f)
+		}
+	})
+}
+
+func TestQuantize(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomMatrix(r, 200, 16)
+	for i := range data {
+		data[i][3] = 2 // constant column
+	}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	int8Tensor, err := dataTensor.Quantize(QuantInt8)
+	if err != nil {
+		t.Fatalf("Quantize(QuantInt8) failed: %v", err)
+	}
+	float16Tensor, err := dataTensor.Quantize(QuantFloat16)
+	if err != nil {
+		t.Fatalf("Quantize(QuantFloat16) failed: %v", err)
+	}
+
+	t.Run("Int8 error bound", func(t *testing.T) {
+		m := int8Tensor.Values.(*Int8Matrix[float32])
+		for i := range data {
+			for j := range data[i] {
+				if d := Abs(m.at(i, j) - data[i][j]); d > m.Scale[j]/2+1e-6 {
+					t.Fatalf("Row %d dimension %d off by %v", i, j, d)
+				}
+			}
+		}
+		if m.at(0, 3) != 2 {
+			t.Errorf("Constant column should be exact, got %v", m.at(0, 3))
+		}
+	})
+
+	queries := randomMatrix(r, 10, 16)
+	for _, quantized := range []*Tensor[float32]{int8Tensor, float16Tensor} {
+		for _, metric := range []int{L1, L2, MIPS, Cosine} {
+			for _, multithread := range []bool{false, true} {
+				for _, q := range queries {
+					queryTensor := &Tensor[float32]{}
+					_ = queryTensor.New(q)
+
+					exact := &Search[float32]{Data: dataTensor, Query: queryTensor}
+					approx := &Search[float32]{Data: quantized, Query: queryTensor, Multithread: multithread}
+
+					want, _ := exact.metric(5, metric)
+					got, err := approx.metric(5, metric)
+					if err != nil {
+						t.Fatalf("%v metric %d failed: %v", quantized.Type, metric, err)
+					}
+
+					for i := range want.Values {
+						tolerance := 0.02 * (1 + math.Abs(float64(want.Values[i])))
+						if math.Abs(float64(got.Values[i]-want.Values[i])) > tolerance {
+							t.Errorf("%v metric %d value %d: got %v, want %v", quantized.Type, metric, i, got.Values[i], want.Values[i])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	t.Run("Error Cases", func(t *testing.T) {
+		if _, err := dataTensor.Quantize(-1); err == nil {
+			t.Error("Expected error for unsupported quantization, got nil")
+		}
+
+		vector := &Tensor[float32]{}
+		_ = vector.New(queries[0])
+		if _, err := vector.Quantize(QuantInt8); err == nil {
+			t.Error("Expected error for quantizing a vector, got nil")
+		}
+
+		s := &Search[float32]{Data: int8Tensor, Query: vector}
+		if _, err := s.Chebyshev(1); err == nil {
+			t.Error("Expected error for unsupported metric on quantized data, got nil")
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		for _, quantized := range []*Tensor[float32]{int8Tensor, float16Tensor} {
+			filename := filepath.Join(t.TempDir(), "quantized.tensor")
+			if err := Export(quantized, filename); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			imported, err := Import[float32](filename)
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if !reflect.DeepEqual(imported, quantized) {
+				t.Errorf("Imported %v tensor does not match", quantized.Type)
+			}
+			os.Remove(filename)
+		}
+	})
+}
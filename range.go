@@ -0,0 +1,91 @@
+package knn
+
+import (
+	"fmt"
+	"math"
+)
+
+// RangeL1 returns every row within radius of the query, nearest first.
+// An optional int caps the number of results.
+func (s *Search[T]) RangeL1(radius T, opts ...interface{}) (Neighbors[T], error) {
+	if err := s.rangeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	limit, err := rangeLimit("RangeL1", opts)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	r := s.within(func(i int) (T, bool) {
+		distance := s.Manhattan(&i)
+		return distance, distance <= radius
+	})
+
+	return r.Neighbors(limit, false), nil
+}
+
+// RangeL2 returns every row within Euclidean radius of the query, nearest
+// first. Values are Euclidean distances rather than the L2 ranking scores,
+// and are computed exactly for every row.
+func (s *Search[T]) RangeL2(radius T, opts ...interface{}) (Neighbors[T], error) {
+	if err := s.rangeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	limit, err := rangeLimit("RangeL2", opts)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	if radius < 0 {
+		return Neighbors[T]{Values: []T{}, Indices: []int{}}, nil
+	}
+
+	// exact squared distances, |x|²/2 - q·x cancels for rows close to the
+	// query
+	query := s.Query.Values.([]T)
+	r2 := float64(radius) * float64(radius)
+
+	r := s.within(func(i int) (T, bool) {
+		d2 := s.squaredDistance(query, i)
+		return T(math.Sqrt(d2)), d2 <= r2
+	})
+
+	return r.Neighbors(limit, false), nil
+}
+
+// RangeMIPS returns every row whose inner product with the query is at
+// least threshold, highest first.
+func (s *Search[T]) RangeMIPS(threshold T, opts ...interface{}) (Neighbors[T], error) {
+	if err := s.rangeChecker(); err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	limit, err := rangeLimit("RangeMIPS", opts)
+	if err != nil {
+		return Neighbors[T]{}, err
+	}
+
+	rowDot := s.rowDot(s.weighted(s.Query.Values.([]T)))
+
+	r := s.within(func(i int) (T, bool) {
+		score := rowDot(i)
+		return score, score >= threshold
+	})
+
+	return r.Neighbors(limit, true), nil
+}
+
+func rangeLimit(name string, opts []interface{}) (int, error) {
+	if len(opts) == 0 {
+		return 0, nil
+	}
+
+	limit, ok := opts[0].(int)
+	if !ok || limit <= 0 {
+		return 0, fmt.Errorf("invalid options for %s", name)
+	}
+
+	return limit, nil
+}
@@ -0,0 +1,140 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRangeSearch(t *testing.T) {
+	data := [][]float32{
+		{1.0, 2.0, 3.0},
+		{4.0, 5.0, 6.0},
+		{7.0, 8.0, 9.0},
+		{10.0, 11.0, 12.0},
+		{3.0, 4.0, 4.0},
+	}
+	query := []float32{3.0, 4.0, 5.0}
+
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+	queryTensor := &Tensor[float32]{}
+	_ = queryTensor.New(query)
+
+	s := &Search[float32]{
+		Data:  dataTensor,
+		Query: queryTensor,
+	}
+
+	tests := []struct {
+		name            string
+		search          func() (Neighbors[float32], error)
+		expectedIndices []int
+		expectedValues  []float32
+	}{
+		{
+			name:            "RangeL1",
+			search:          func() (Neighbors[float32], error) { return s.RangeL1(6) },
+			expectedIndices: []int{4, 1, 0},
+			expectedValues:  []float32{1, 3, 6},
+		},
+		{
+			name:            "RangeL1 capped",
+			search:          func() (Neighbors[float32], error) { return s.RangeL1(6, 2) },
+			expectedIndices: []int{4, 1},
+			expectedValues:  []float32{1, 3},
+		},
+		{
+			name:            "RangeL2",
+			search:          func() (Neighbors[float32], error) { return s.RangeL2(3.5) },
+			expectedIndices: []int{4, 1, 0},
+			expectedValues:  []float32{1, float32(math.Sqrt(3)), float32(math.Sqrt(12))},
+		},
+		{
+			name:            "RangeL2 empty",
+			search:          func() (Neighbors[float32], error) { return s.RangeL2(0.5) },
+			expectedIndices: []int{},
+			expectedValues:  []float32{},
+		},
+		{
+			name:            "RangeMIPS",
+			search:          func() (Neighbors[float32], error) { return s.RangeMIPS(62) },
+			expectedIndices: []int{3, 2, 1},
+			expectedValues:  []float32{134, 98, 62},
+		},
+		{
+			name:            "RangeMIPS capped",
+			search:          func() (Neighbors[float32], error) { return s.RangeMIPS(0, 1) },
+			expectedIndices: []int{3},
+			expectedValues:  []float32{134},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neighbors, err := tt.search()
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+
+			if !reflect.DeepEqual(neighbors.Indices, tt.expectedIndices) {
+				t.Errorf("Indices mismatch. Got %v, want %v", neighbors.Indices, tt.expectedIndices)
+			}
+			for i, v := range neighbors.Values {
+				if math.Abs(float64(v-tt.expectedValues[i])) > 1e-5 {
+					t.Errorf("Value mismatch at index %d. Got %f, want %f", i, v, tt.expectedValues[i])
+				}
+			}
+		})
+	}
+
+	t.Run("Error Cases", func(t *testing.T) {
+		if _, err := s.RangeL1(1, "10"); err == nil {
+			t.Error("Expected error for invalid limit, got nil")
+		}
+		if _, err := s.RangeL2(1, 0); err == nil {
+			t.Error("Expected error for zero limit, got nil")
+		}
+
+		invalidQuery := &Tensor[float32]{}
+		_ = invalidQuery.New([]float32{1.0, 2.0})
+		invalidSearch := &Search[float32]{Data: dataTensor, Query: invalidQuery}
+		if _, err := invalidSearch.RangeMIPS(0); err == nil {
+			t.Error("Expected error for mismatched dimensions, got nil")
+		}
+	})
+}
+
+func TestRangeL2Precision(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([][]float32, 100)
+	for i := range data {
+		data[i] = make([]float32, 64)
+		for j := range data[i] {
+			data[i][j] = 100 + 1000*r.Float32()
+		}
+	}
+	dataTensor := &Tensor[float32]{}
+	_ = dataTensor.New(data)
+
+	for trial := 0; trial < 50; trial++ {
+		row := r.Intn(len(data))
+		queryTensor := &Tensor[float32]{}
+		_ = queryTensor.New(append([]float32{}, data[row]...))
+
+		s := &Search[float32]{Data: dataTensor, Query: queryTensor}
+		neighbors, err := s.RangeL2(1)
+		if err != nil {
+			t.Fatalf("RangeL2 failed: %v", err)
+		}
+		if len(neighbors.Indices) != 1 || neighbors.Indices[0] != row || neighbors.Values[0] != 0 {
+			t.Fatalf("Expected duplicate row %d at distance 0, got %v", row, neighbors)
+		}
+
+		// radius² must not turn a negative radius into a positive one
+		if neighbors, _ := s.RangeL2(-1); len(neighbors.Indices) != 0 {
+			t.Fatalf("Expected no rows for a negative radius, got %v", neighbors)
+		}
+	}
+}
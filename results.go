@@ -2,6 +2,7 @@ package knn
 
 import (
 	"container/heap"
+	"sort"
 )
 
 type Result[T float32 | float64] struct {
@@ -14,7 +15,7 @@ type MaxHeap[T float32 | float64] struct {
 }
 
 func (h *MaxHeap[T]) Len() int           { return len(h.results) }
-func (h *MaxHeap[T]) Less(i, j int) bool { return h.results[i].Distance > h.results[j].Distance }
+func (h *MaxHeap[T]) Less(i, j int) bool { return worse(h.results[i], h.results[j]) }
 func (h *MaxHeap[T]) Swap(i, j int)      { h.results[i], h.results[j] = h.results[j], h.results[i] }
 func (h *MaxHeap[T]) Push(x interface{}) {
 	h.results = append(h.results, x.(Result[T]))
@@ -32,8 +33,62 @@ func (h *MaxHeap[T]) Peek() interface{} {
 func (h *MaxHeap[T]) Process(i *int, k *int, distance *T) {
 	if h.Len() < *k {
 		heap.Push(h, Result[T]{Index: *i, Distance: *distance})
-	} else if *distance < h.Peek().(Result[T]).Distance {
+	} else if worse(h.Peek().(Result[T]), Result[T]{Index: *i, Distance: *distance}) {
 		heap.Pop(h)
 		heap.Push(h, Result[T]{Index: *i, Distance: *distance})
 	}
 }
+
+// worse orders by distance, then index, so ties keep the lowest indices.
+func worse[T float32 | float64](a, b Result[T]) bool {
+	if a.Distance == b.Distance {
+		return a.Index > b.Index
+	}
+	return a.Distance > b.Distance
+}
+
+// Results collects every match of a range search, unlike MaxHeap which
+// only keeps k.
+type Results[T float32 | float64] []Result[T]
+
+func (r *Results[T]) Process(i *int, distance *T) {
+	*r = append(*r, Result[T]{Index: *i, Distance: *distance})
+}
+
+// Neighbors sorts the results, nearest first, and keeps at most limit of
+// them when limit > 0. Descending sorts largest first for similarities.
+func (r Results[T]) Neighbors(limit int, descending bool) Neighbors[T] {
+	if descending {
+		sort.Slice(r, func(i, j int) bool {
+			if r[i].Distance == r[j].Distance {
+				return r[i].Index < r[j].Index
+			}
+			return r[i].Distance > r[j].Distance
+		})
+	} else {
+		sortResults(r)
+	}
+
+	if limit > 0 && len(r) > limit {
+		r = r[:limit]
+	}
+
+	indices := make([]int, len(r))
+	values := make([]T, len(r))
+	for i, result := range r {
+		indices[i] = result.Index
+		values[i] = result.Distance
+	}
+
+	return Neighbors[T]{Values: values, Indices: indices}
+}
+
+// sortResults orders by distance, then index, nearest first.
+func sortResults[T float32 | float64](r []Result[T]) {
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Distance == r[j].Distance {
+			return r[i].Index < r[j].Index
+		}
+		return r[i].Distance < r[j].Distance
+	})
+}
@@ -107,6 +107,37 @@ func TestMaxHeap(t *testing.T) {
 	})
 }
 
+func TestResults(t *testing.T) {
+	items := []struct {
+		index    int
+		distance float32
+	}{
+		{0, 5.0},
+		{1, 3.0},
+		{2, 7.0},
+		{3, 3.0},
+	}
+
+	r := &Results[float32]{}
+	for _, item := range items {
+		r.Process(&item.index, &item.distance)
+	}
+
+	t.Run("Ascending", func(t *testing.T) {
+		expected := Neighbors[float32]{Indices: []int{1, 3, 0, 2}, Values: []float32{3, 3, 5, 7}}
+		if got := r.Neighbors(0, false); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("Descending with limit", func(t *testing.T) {
+		expected := Neighbors[float32]{Indices: []int{2, 0}, Values: []float32{7, 5}}
+		if got := r.Neighbors(2, true); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	})
+}
+
 func BenchmarkMaxHeap(b *testing.B) {
 	b.Run("Push and Pop", func(b *testing.B) {
 		h := &MaxHeap[float64]{}
@@ -0,0 +1,138 @@
+//go:build amd64
+
+package knn
+
+// Kernels are picked at startup from the CPU features, falling back to the
+// unrolled Go kernels without AVX2 and FMA.
+var (
+	hasAVX2   bool
+	hasAVX512 bool
+)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+func xgetbv() (eax, edx uint32)
+
+func init() {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	fma := ecx1&(1<<12) != 0
+	osxsave := ecx1&(1<<27) != 0
+	avx := ecx1&(1<<28) != 0
+	if !fma || !osxsave || !avx {
+		return
+	}
+
+	// the OS must save the YMM (and ZMM) registers
+	xcr0, _ := xgetbv()
+	_, ebx7, _, _ := cpuid(7, 0)
+
+	hasAVX2 = xcr0&0x6 == 0x6 && ebx7&(1<<5) != 0
+	hasAVX512 = hasAVX2 && xcr0&0xe6 == 0xe6 && ebx7&(1<<16) != 0
+}
+
+//go:noescape
+func l1AVX2F32(a, b []float32) float32
+
+//go:noescape
+func l1AVX2F64(a, b []float64) float64
+
+//go:noescape
+func l1AVX512F32(a, b []float32) float32
+
+//go:noescape
+func l1AVX512F64(a, b []float64) float64
+
+//go:noescape
+func dotAVX2F32(a, b []float32) float32
+
+//go:noescape
+func dotAVX2F64(a, b []float64) float64
+
+//go:noescape
+func dotAVX512F32(a, b []float32) float32
+
+//go:noescape
+func dotAVX512F64(a, b []float64) float64
+
+//go:noescape
+func sqnormAVX2F32(a []float32) float32
+
+//go:noescape
+func sqnormAVX2F64(a []float64) float64
+
+//go:noescape
+func sqnormAVX512F32(a []float32) float32
+
+//go:noescape
+func sqnormAVX512F64(a []float64) float64
+
+func manhattanSIMD[T float32 | float64](query, data []T) T {
+	switch q := any(query).(type) {
+	case []float32:
+		d := any(data).([]float32)[:len(q)]
+		if hasAVX512 {
+			return T(l1AVX512F32(q, d))
+		}
+		if hasAVX2 {
+			return T(l1AVX2F32(q, d))
+		}
+	case []float64:
+		d := any(data).([]float64)[:len(q)]
+		if hasAVX512 {
+			return T(l1AVX512F64(q, d))
+		}
+		if hasAVX2 {
+			return T(l1AVX2F64(q, d))
+		}
+	}
+
+	return manhattanUnrolled(query, data)
+}
+
+func dotSIMD[T float32 | float64](query, data []T) T {
+	switch q := any(query).(type) {
+	case []float32:
+		d := any(data).([]float32)[:len(q)]
+		if hasAVX512 {
+			return T(dotAVX512F32(q, d))
+		}
+		if hasAVX2 {
+			return T(dotAVX2F32(q, d))
+		}
+	case []float64:
+		d := any(data).([]float64)[:len(q)]
+		if hasAVX512 {
+			return T(dotAVX512F64(q, d))
+		}
+		if hasAVX2 {
+			return T(dotAVX2F64(q, d))
+		}
+	}
+
+	return dot(query, data)
+}
+
+func squaredNormSIMD[T float32 | float64](v []T) T {
+	switch a := any(v).(type) {
+	case []float32:
+		if hasAVX512 {
+			return T(sqnormAVX512F32(a))
+		}
+		if hasAVX2 {
+			return T(sqnormAVX2F32(a))
+		}
+	case []float64:
+		if hasAVX512 {
+			return T(sqnormAVX512F64(a))
+		}
+		if hasAVX2 {
+			return T(sqnormAVX2F64(a))
+		}
+	}
+
+	return dot(v, v)
+}
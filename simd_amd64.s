@@ -0,0 +1,768 @@
+//go:build amd64
+
+#include "textflag.h"
+
+DATA absf32<>+0(SB)/4, $0x7fffffff
+GLOBL absf32<>(SB), RODATA|NOPTR, $4
+
+DATA absf64<>+0(SB)/8, $0x7fffffffffffffff
+GLOBL absf64<>(SB), RODATA|NOPTR, $8
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
+
+// func l1AVX2F32(a, b []float32) float32
+TEXT ·l1AVX2F32(SB), NOSPLIT, $0-52
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VBROADCASTSS absf32<>(SB), Y15
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1y:
+	CMPQ CX, $16
+	JL   l1y1
+	VMOVUPS (SI), Y2
+	VSUBPS (DI), Y2, Y2
+	VANDPS Y15, Y2, Y2
+	VADDPS Y2, Y0, Y0
+	VMOVUPS 32(SI), Y3
+	VSUBPS 32(DI), Y3, Y3
+	VANDPS Y15, Y3, Y3
+	VADDPS Y3, Y1, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $16, CX
+	JMP  l1y
+
+l1y1:
+	CMPQ CX, $8
+	JL   l1reduce
+	VMOVUPS (SI), Y2
+	VSUBPS (DI), Y2, Y2
+	VANDPS Y15, Y2, Y2
+	VADDPS Y2, Y0, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+
+l1reduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+l1tail:
+	TESTQ CX, CX
+	JE   l1done
+	VMOVSS (SI), X2
+	VSUBSS (DI), X2, X2
+	VANDPS X15, X2, X2
+	VADDSS X2, X0, X0
+	ADDQ $4, SI
+	ADDQ $4, DI
+	DECQ CX
+	JMP  l1tail
+
+l1done:
+	VZEROUPPER
+	VMOVSS X0, ret+48(FP)
+	RET
+
+// func l1AVX2F64(a, b []float64) float64
+TEXT ·l1AVX2F64(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VBROADCASTSD absf64<>(SB), Y15
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1y:
+	CMPQ CX, $8
+	JL   l1y1
+	VMOVUPD (SI), Y2
+	VSUBPD (DI), Y2, Y2
+	VANDPD Y15, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	VMOVUPD 32(SI), Y3
+	VSUBPD 32(DI), Y3, Y3
+	VANDPD Y15, Y3, Y3
+	VADDPD Y3, Y1, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $8, CX
+	JMP  l1y
+
+l1y1:
+	CMPQ CX, $4
+	JL   l1reduce
+	VMOVUPD (SI), Y2
+	VSUBPD (DI), Y2, Y2
+	VANDPD Y15, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $4, CX
+
+l1reduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+l1tail:
+	TESTQ CX, CX
+	JE   l1done
+	VMOVSD (SI), X2
+	VSUBSD (DI), X2, X2
+	VANDPD X15, X2, X2
+	VADDSD X2, X0, X0
+	ADDQ $8, SI
+	ADDQ $8, DI
+	DECQ CX
+	JMP  l1tail
+
+l1done:
+	VZEROUPPER
+	VMOVSD X0, ret+48(FP)
+	RET
+
+// func l1AVX512F32(a, b []float32) float32
+TEXT ·l1AVX512F32(SB), NOSPLIT, $0-52
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VBROADCASTSS absf32<>(SB), Z15
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1z:
+	CMPQ CX, $32
+	JL   l1zfold
+	VMOVUPS (SI), Z2
+	VSUBPS (DI), Z2, Z2
+	VPANDD Z15, Z2, Z2
+	VADDPS Z2, Z0, Z0
+	VMOVUPS 64(SI), Z3
+	VSUBPS 64(DI), Z3, Z3
+	VPANDD Z15, Z3, Z3
+	VADDPS Z3, Z1, Z1
+	ADDQ $128, SI
+	ADDQ $128, DI
+	SUBQ $32, CX
+	JMP  l1z
+
+l1zfold:
+	VADDPS Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPS Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1y:
+	CMPQ CX, $16
+	JL   l1y1
+	VMOVUPS (SI), Y2
+	VSUBPS (DI), Y2, Y2
+	VANDPS Y15, Y2, Y2
+	VADDPS Y2, Y0, Y0
+	VMOVUPS 32(SI), Y3
+	VSUBPS 32(DI), Y3, Y3
+	VANDPS Y15, Y3, Y3
+	VADDPS Y3, Y1, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $16, CX
+	JMP  l1y
+
+l1y1:
+	CMPQ CX, $8
+	JL   l1reduce
+	VMOVUPS (SI), Y2
+	VSUBPS (DI), Y2, Y2
+	VANDPS Y15, Y2, Y2
+	VADDPS Y2, Y0, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+
+l1reduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+l1tail:
+	TESTQ CX, CX
+	JE   l1done
+	VMOVSS (SI), X2
+	VSUBSS (DI), X2, X2
+	VANDPS X15, X2, X2
+	VADDSS X2, X0, X0
+	ADDQ $4, SI
+	ADDQ $4, DI
+	DECQ CX
+	JMP  l1tail
+
+l1done:
+	VZEROUPPER
+	VMOVSS X0, ret+48(FP)
+	RET
+
+// func l1AVX512F64(a, b []float64) float64
+TEXT ·l1AVX512F64(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VBROADCASTSD absf64<>(SB), Z15
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1z:
+	CMPQ CX, $16
+	JL   l1zfold
+	VMOVUPD (SI), Z2
+	VSUBPD (DI), Z2, Z2
+	VPANDQ Z15, Z2, Z2
+	VADDPD Z2, Z0, Z0
+	VMOVUPD 64(SI), Z3
+	VSUBPD 64(DI), Z3, Z3
+	VPANDQ Z15, Z3, Z3
+	VADDPD Z3, Z1, Z1
+	ADDQ $128, SI
+	ADDQ $128, DI
+	SUBQ $16, CX
+	JMP  l1z
+
+l1zfold:
+	VADDPD Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPD Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+l1y:
+	CMPQ CX, $8
+	JL   l1y1
+	VMOVUPD (SI), Y2
+	VSUBPD (DI), Y2, Y2
+	VANDPD Y15, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	VMOVUPD 32(SI), Y3
+	VSUBPD 32(DI), Y3, Y3
+	VANDPD Y15, Y3, Y3
+	VADDPD Y3, Y1, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $8, CX
+	JMP  l1y
+
+l1y1:
+	CMPQ CX, $4
+	JL   l1reduce
+	VMOVUPD (SI), Y2
+	VSUBPD (DI), Y2, Y2
+	VANDPD Y15, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $4, CX
+
+l1reduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+l1tail:
+	TESTQ CX, CX
+	JE   l1done
+	VMOVSD (SI), X2
+	VSUBSD (DI), X2, X2
+	VANDPD X15, X2, X2
+	VADDSD X2, X0, X0
+	ADDQ $8, SI
+	ADDQ $8, DI
+	DECQ CX
+	JMP  l1tail
+
+l1done:
+	VZEROUPPER
+	VMOVSD X0, ret+48(FP)
+	RET
+
+// func dotAVX2F32(a, b []float32) float32
+TEXT ·dotAVX2F32(SB), NOSPLIT, $0-52
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+doty:
+	CMPQ CX, $16
+	JL   doty1
+	VMOVUPS (SI), Y2
+	VFMADD231PS (DI), Y2, Y0
+	VMOVUPS 32(SI), Y3
+	VFMADD231PS 32(DI), Y3, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $16, CX
+	JMP  doty
+
+doty1:
+	CMPQ CX, $8
+	JL   dotreduce
+	VMOVUPS (SI), Y2
+	VFMADD231PS (DI), Y2, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+
+dotreduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+dottail:
+	TESTQ CX, CX
+	JE   dotdone
+	VMOVSS (SI), X2
+	VFMADD231SS (DI), X2, X0
+	ADDQ $4, SI
+	ADDQ $4, DI
+	DECQ CX
+	JMP  dottail
+
+dotdone:
+	VZEROUPPER
+	VMOVSS X0, ret+48(FP)
+	RET
+
+// func dotAVX2F64(a, b []float64) float64
+TEXT ·dotAVX2F64(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+doty:
+	CMPQ CX, $8
+	JL   doty1
+	VMOVUPD (SI), Y2
+	VFMADD231PD (DI), Y2, Y0
+	VMOVUPD 32(SI), Y3
+	VFMADD231PD 32(DI), Y3, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $8, CX
+	JMP  doty
+
+doty1:
+	CMPQ CX, $4
+	JL   dotreduce
+	VMOVUPD (SI), Y2
+	VFMADD231PD (DI), Y2, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $4, CX
+
+dotreduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+dottail:
+	TESTQ CX, CX
+	JE   dotdone
+	VMOVSD (SI), X2
+	VFMADD231SD (DI), X2, X0
+	ADDQ $8, SI
+	ADDQ $8, DI
+	DECQ CX
+	JMP  dottail
+
+dotdone:
+	VZEROUPPER
+	VMOVSD X0, ret+48(FP)
+	RET
+
+// func dotAVX512F32(a, b []float32) float32
+TEXT ·dotAVX512F32(SB), NOSPLIT, $0-52
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+dotz:
+	CMPQ CX, $32
+	JL   dotzfold
+	VMOVUPS (SI), Z2
+	VFMADD231PS (DI), Z2, Z0
+	VMOVUPS 64(SI), Z3
+	VFMADD231PS 64(DI), Z3, Z1
+	ADDQ $128, SI
+	ADDQ $128, DI
+	SUBQ $32, CX
+	JMP  dotz
+
+dotzfold:
+	VADDPS Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPS Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+doty:
+	CMPQ CX, $16
+	JL   doty1
+	VMOVUPS (SI), Y2
+	VFMADD231PS (DI), Y2, Y0
+	VMOVUPS 32(SI), Y3
+	VFMADD231PS 32(DI), Y3, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $16, CX
+	JMP  doty
+
+doty1:
+	CMPQ CX, $8
+	JL   dotreduce
+	VMOVUPS (SI), Y2
+	VFMADD231PS (DI), Y2, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+
+dotreduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+dottail:
+	TESTQ CX, CX
+	JE   dotdone
+	VMOVSS (SI), X2
+	VFMADD231SS (DI), X2, X0
+	ADDQ $4, SI
+	ADDQ $4, DI
+	DECQ CX
+	JMP  dottail
+
+dotdone:
+	VZEROUPPER
+	VMOVSS X0, ret+48(FP)
+	RET
+
+// func dotAVX512F64(a, b []float64) float64
+TEXT ·dotAVX512F64(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+dotz:
+	CMPQ CX, $16
+	JL   dotzfold
+	VMOVUPD (SI), Z2
+	VFMADD231PD (DI), Z2, Z0
+	VMOVUPD 64(SI), Z3
+	VFMADD231PD 64(DI), Z3, Z1
+	ADDQ $128, SI
+	ADDQ $128, DI
+	SUBQ $16, CX
+	JMP  dotz
+
+dotzfold:
+	VADDPD Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPD Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+doty:
+	CMPQ CX, $8
+	JL   doty1
+	VMOVUPD (SI), Y2
+	VFMADD231PD (DI), Y2, Y0
+	VMOVUPD 32(SI), Y3
+	VFMADD231PD 32(DI), Y3, Y1
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $8, CX
+	JMP  doty
+
+doty1:
+	CMPQ CX, $4
+	JL   dotreduce
+	VMOVUPD (SI), Y2
+	VFMADD231PD (DI), Y2, Y0
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $4, CX
+
+dotreduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+dottail:
+	TESTQ CX, CX
+	JE   dotdone
+	VMOVSD (SI), X2
+	VFMADD231SD (DI), X2, X0
+	ADDQ $8, SI
+	ADDQ $8, DI
+	DECQ CX
+	JMP  dottail
+
+dotdone:
+	VZEROUPPER
+	VMOVSD X0, ret+48(FP)
+	RET
+
+// func sqnormAVX2F32(a []float32) float32
+TEXT ·sqnormAVX2F32(SB), NOSPLIT, $0-28
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormy:
+	CMPQ CX, $16
+	JL   sqnormy1
+	VMOVUPS (SI), Y2
+	VFMADD231PS Y2, Y2, Y0
+	VMOVUPS 32(SI), Y3
+	VFMADD231PS Y3, Y3, Y1
+	ADDQ $64, SI
+	SUBQ $16, CX
+	JMP  sqnormy
+
+sqnormy1:
+	CMPQ CX, $8
+	JL   sqnormreduce
+	VMOVUPS (SI), Y2
+	VFMADD231PS Y2, Y2, Y0
+	ADDQ $32, SI
+	SUBQ $8, CX
+
+sqnormreduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+sqnormtail:
+	TESTQ CX, CX
+	JE   sqnormdone
+	VMOVSS (SI), X2
+	VFMADD231SS X2, X2, X0
+	ADDQ $4, SI
+	DECQ CX
+	JMP  sqnormtail
+
+sqnormdone:
+	VZEROUPPER
+	VMOVSS X0, ret+24(FP)
+	RET
+
+// func sqnormAVX2F64(a []float64) float64
+TEXT ·sqnormAVX2F64(SB), NOSPLIT, $0-32
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormy:
+	CMPQ CX, $8
+	JL   sqnormy1
+	VMOVUPD (SI), Y2
+	VFMADD231PD Y2, Y2, Y0
+	VMOVUPD 32(SI), Y3
+	VFMADD231PD Y3, Y3, Y1
+	ADDQ $64, SI
+	SUBQ $8, CX
+	JMP  sqnormy
+
+sqnormy1:
+	CMPQ CX, $4
+	JL   sqnormreduce
+	VMOVUPD (SI), Y2
+	VFMADD231PD Y2, Y2, Y0
+	ADDQ $32, SI
+	SUBQ $4, CX
+
+sqnormreduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+sqnormtail:
+	TESTQ CX, CX
+	JE   sqnormdone
+	VMOVSD (SI), X2
+	VFMADD231SD X2, X2, X0
+	ADDQ $8, SI
+	DECQ CX
+	JMP  sqnormtail
+
+sqnormdone:
+	VZEROUPPER
+	VMOVSD X0, ret+24(FP)
+	RET
+
+// func sqnormAVX512F32(a []float32) float32
+TEXT ·sqnormAVX512F32(SB), NOSPLIT, $0-28
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormz:
+	CMPQ CX, $32
+	JL   sqnormzfold
+	VMOVUPS (SI), Z2
+	VFMADD231PS Z2, Z2, Z0
+	VMOVUPS 64(SI), Z3
+	VFMADD231PS Z3, Z3, Z1
+	ADDQ $128, SI
+	SUBQ $32, CX
+	JMP  sqnormz
+
+sqnormzfold:
+	VADDPS Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPS Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormy:
+	CMPQ CX, $16
+	JL   sqnormy1
+	VMOVUPS (SI), Y2
+	VFMADD231PS Y2, Y2, Y0
+	VMOVUPS 32(SI), Y3
+	VFMADD231PS Y3, Y3, Y1
+	ADDQ $64, SI
+	SUBQ $16, CX
+	JMP  sqnormy
+
+sqnormy1:
+	CMPQ CX, $8
+	JL   sqnormreduce
+	VMOVUPS (SI), Y2
+	VFMADD231PS Y2, Y2, Y0
+	ADDQ $32, SI
+	SUBQ $8, CX
+
+sqnormreduce:
+	VADDPS Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS X1, X0, X0
+	VHADDPS X0, X0, X0
+	VHADDPS X0, X0, X0
+
+sqnormtail:
+	TESTQ CX, CX
+	JE   sqnormdone
+	VMOVSS (SI), X2
+	VFMADD231SS X2, X2, X0
+	ADDQ $4, SI
+	DECQ CX
+	JMP  sqnormtail
+
+sqnormdone:
+	VZEROUPPER
+	VMOVSS X0, ret+24(FP)
+	RET
+
+// func sqnormAVX512F64(a []float64) float64
+TEXT ·sqnormAVX512F64(SB), NOSPLIT, $0-32
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormz:
+	CMPQ CX, $16
+	JL   sqnormzfold
+	VMOVUPD (SI), Z2
+	VFMADD231PD Z2, Z2, Z0
+	VMOVUPD 64(SI), Z3
+	VFMADD231PD Z3, Z3, Z1
+	ADDQ $128, SI
+	SUBQ $16, CX
+	JMP  sqnormz
+
+sqnormzfold:
+	VADDPD Z1, Z0, Z0
+	VEXTRACTF64X4 $1, Z0, Y1
+	VADDPD Y1, Y0, Y0
+	VXORPS Y1, Y1, Y1
+
+sqnormy:
+	CMPQ CX, $8
+	JL   sqnormy1
+	VMOVUPD (SI), Y2
+	VFMADD231PD Y2, Y2, Y0
+	VMOVUPD 32(SI), Y3
+	VFMADD231PD Y3, Y3, Y1
+	ADDQ $64, SI
+	SUBQ $8, CX
+	JMP  sqnormy
+
+sqnormy1:
+	CMPQ CX, $4
+	JL   sqnormreduce
+	VMOVUPD (SI), Y2
+	VFMADD231PD Y2, Y2, Y0
+	ADDQ $32, SI
+	SUBQ $4, CX
+
+sqnormreduce:
+	VADDPD Y1, Y0, Y0
+	VEXTRACTF128 $1, Y0, X1
+	VADDPD X1, X0, X0
+	VHADDPD X0, X0, X0
+
+sqnormtail:
+	TESTQ CX, CX
+	JE   sqnormdone
+	VMOVSD (SI), X2
+	VFMADD231SD X2, X2, X0
+	ADDQ $8, SI
+	DECQ CX
+	JMP  sqnormtail
+
+sqnormdone:
+	VZEROUPPER
+	VMOVSD X0, ret+24(FP)
+	RET
@@ -0,0 +1,87 @@
+//go:build amd64
+
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAMD64Kernels(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	isas := []struct {
+		name      string
+		supported bool
+		l1F32     func(a, b []float32) float32
+		l1F64     func(a, b []float64) float64
+		dotF32    func(a, b []float32) float32
+		dotF64    func(a, b []float64) float64
+		sqnormF32 func(a []float32) float32
+		sqnormF64 func(a []float64) float64
+	}{
+		{"AVX2", hasAVX2, l1AVX2F32, l1AVX2F64, dotAVX2F32, dotAVX2F64, sqnormAVX2F32, sqnormAVX2F64},
+		{"AVX512", hasAVX512, l1AVX512F32, l1AVX512F64, dotAVX512F32, dotAVX512F64, sqnormAVX512F32, sqnormAVX512F64},
+	}
+
+	near := func(got, want float64) bool {
+		return math.Abs(got-want) <= 1e-4*(1+math.Abs(want))
+	}
+
+	for _, isa := range isas {
+		t.Run(isa.name, func(t *testing.T) {
+			if !isa.supported {
+				t.Skipf("%s is not supported on this CPU", isa.name)
+			}
+
+			for _, n := range []int{0, 1, 3, 4, 7, 8, 9, 15, 16, 17, 31, 32, 33, 63, 64, 65, 100, 1000} {
+				a64 := make([]float64, n)
+				b64 := make([]float64, n)
+				a32 := make([]float32, n)
+				b32 := make([]float32, n)
+				for j := 0; j < n; j++ {
+					a64[j], b64[j] = r.NormFloat64(), r.NormFloat64()
+					a32[j], b32[j] = float32(a64[j]), float32(b64[j])
+				}
+
+				if got, want := float64(isa.l1F32(a32, b32)), float64(manhattanUnrolled(a32, b32)); !near(got, want) {
+					t.Errorf("l1F32 n=%d: got %v, want %v", n, got, want)
+				}
+				if got, want := isa.l1F64(a64, b64), manhattanUnrolled(a64, b64); !near(got, want) {
+					t.Errorf("l1F64 n=%d: got %v, want %v", n, got, want)
+				}
+				if got, want := float64(isa.dotF32(a32, b32)), float64(dot(a32, b32)); !near(got, want) {
+					t.Errorf("dotF32 n=%d: got %v, want %v", n, got, want)
+				}
+				if got, want := isa.dotF64(a64, b64), dot(a64, b64); !near(got, want) {
+					t.Errorf("dotF64 n=%d: got %v, want %v", n, got, want)
+				}
+				if got, want := float64(isa.sqnormF32(a32)), float64(dot(a32, a32)); !near(got, want) {
+					t.Errorf("sqnormF32 n=%d: got %v, want %v", n, got, want)
+				}
+				if got, want := isa.sqnormF64(a64), dot(a64, a64); !near(got, want) {
+					t.Errorf("sqnormF64 n=%d: got %v, want %v", n, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("Fallback", func(t *testing.T) {
+		avx2, avx512 := hasAVX2, hasAVX512
+		defer func() { hasAVX2, hasAVX512 = avx2, avx512 }()
+		hasAVX2, hasAVX512 = false, false
+
+		a := []float64{1, -2, 3, 4, 5}
+		b := []float64{0, 2, 1, 4, -5}
+		if got := manhattanSIMD(a, b); got != 17 {
+			t.Errorf("manhattanSIMD = %v, want 17", got)
+		}
+		if got := dotSIMD(a, b); got != -10 {
+			t.Errorf("dotSIMD = %v, want -10", got)
+		}
+		if got := squaredNormSIMD(a); got != 55 {
+			t.Errorf("squaredNormSIMD = %v, want 55", got)
+		}
+	})
+}
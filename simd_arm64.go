@@ -0,0 +1,128 @@
+//go:build arm64 && cgo
+
+package knn
+
+import (
+	"unsafe"
+
+	"github.com/alivanz/go-simd/arm"
+	"github.com/alivanz/go-simd/arm/neon"
+)
+
+// The NEON kernels load 128-bit vectors straight from the slices and keep
+// a vector accumulator, reducing lanes once at the end. float32 runs 4
+// lanes wide and float64 2 lanes wide.
+
+func manhattanSIMD[T float32 | float64](query, data []T) T {
+	switch q := any(query).(type) {
+	case []float32:
+		return T(manhattanF32(q, any(data).([]float32)))
+	case []float64:
+		return T(manhattanF64(q, any(data).([]float64)))
+	}
+	return manhattanUnrolled(query, data)
+}
+
+func dotSIMD[T float32 | float64](query, data []T) T {
+	switch q := any(query).(type) {
+	case []float32:
+		return T(dotF32(q, any(data).([]float32)))
+	case []float64:
+		return T(dotF64(q, any(data).([]float64)))
+	}
+	return dot(query, data)
+}
+
+func squaredNormSIMD[T float32 | float64](v []T) T {
+	return dotSIMD(v, v)
+}
+
+func manhattanF32(query, data []float32) float32 {
+	var acc, diff arm.Float32X4
+	n := len(query)
+	data = data[:n]
+
+	for j := 0; j < n-3; j += 4 {
+		a := (*arm.Float32X4)(unsafe.Pointer(&query[j]))
+		b := (*arm.Float32X4)(unsafe.Pointer(&data[j]))
+		neon.VabdqF32(&diff, a, b)
+		neon.VaddqF32(&acc, &acc, &diff)
+	}
+
+	var lanes arm.Float32
+	neon.VaddvqF32(&lanes, &acc)
+
+	sum := float32(lanes)
+	for j := n - n%4; j < n; j++ {
+		sum += Abs(query[j] - data[j])
+	}
+
+	return sum
+}
+
+func manhattanF64(query, data []float64) float64 {
+	var acc, diff arm.Float64X2
+	n := len(query)
+	data = data[:n]
+
+	for j := 0; j < n-1; j += 2 {
+		a := (*arm.Float64X2)(unsafe.Pointer(&query[j]))
+		b := (*arm.Float64X2)(unsafe.Pointer(&data[j]))
+		neon.VabdqF64(&diff, a, b)
+		neon.VaddqF64(&acc, &acc, &diff)
+	}
+
+	var lanes arm.Float64
+	neon.VaddvqF64(&lanes, &acc)
+
+	sum := float64(lanes)
+	if n%2 == 1 {
+		sum += Abs(query[n-1] - data[n-1])
+	}
+
+	return sum
+}
+
+func dotF32(query, data []float32) float32 {
+	var acc arm.Float32X4
+	n := len(query)
+	data = data[:n]
+
+	for j := 0; j < n-3; j += 4 {
+		a := (*arm.Float32X4)(unsafe.Pointer(&query[j]))
+		b := (*arm.Float32X4)(unsafe.Pointer(&data[j]))
+		neon.VfmaqF32(&acc, &acc, a, b)
+	}
+
+	var lanes arm.Float32
+	neon.VaddvqF32(&lanes, &acc)
+
+	sum := float32(lanes)
+	for j := n - n%4; j < n; j++ {
+		sum += query[j] * data[j]
+	}
+
+	return sum
+}
+
+func dotF64(query, data []float64) float64 {
+	var acc arm.Float64X2
+	n := len(query)
+	data = data[:n]
+
+	for j := 0; j < n-1; j += 2 {
+		a := (*arm.Float64X2)(unsafe.Pointer(&query[j]))
+		b := (*arm.Float64X2)(unsafe.Pointer(&data[j]))
+		neon.VfmaqF64(&acc, &acc, a, b)
+	}
+
+	var lanes arm.Float64
+	neon.VaddvqF64(&lanes, &acc)
+
+	sum := float64(lanes)
+	if n%2 == 1 {
+		sum += query[n-1] * data[n-1]
+	}
+
+	return sum
+}
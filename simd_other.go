@@ -0,0 +1,17 @@
+//go:build !amd64 && !(arm64 && cgo)
+
+package knn
+
+// No vector kernels on this architecture, SIMD uses the unrolled kernels.
+
+func manhattanSIMD[T float32 | float64](query, data []T) T {
+	return manhattanUnrolled(query, data)
+}
+
+func dotSIMD[T float32 | float64](query, data []T) T {
+	return dot(query, data)
+}
+
+func squaredNormSIMD[T float32 | float64](v []T) T {
+	return dot(v, v)
+}
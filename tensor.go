@@ -8,32 +8,54 @@ import (
 	"reflect"
 )
 
+// Tensor holds a vector or a matrix. Matrices built with New are stored
+// contiguously in row-major order, and Values holds [][]T views of the
+// rows for compatibility. Tensors opened with OpenMmap have no Values,
+// their rows are read with Row or Flat.
 type Tensor[T float32 | float64] struct {
 	Values interface{}
 	Shape  [2]int
 	Type   reflect.Type
 	Rank   int
+
+	flat   []T
+	stride int
+	mapped []byte
 }
 
-func (t *Tensor[T]) New(values interface{}) error {
+// New builds a tensor from a []T vector or a [][]T matrix, which is copied
+// into contiguous storage. A []T with a (rows, cols) shape is used as a
+// row-major matrix without copying.
+func (t *Tensor[T]) New(values interface{}, shape ...int) error {
+	if flat, ok := values.([]T); ok && len(shape) > 0 {
+		if len(shape) != 2 || shape[0] < 1 || shape[1] < 1 {
+			return fmt.Errorf("invalid shape: %v", shape)
+		}
+		if len(flat) != shape[0]*shape[1] {
+			return fmt.Errorf("expected %d values for shape %v, got %d", shape[0]*shape[1], shape, len(flat))
+		}
+		t.setFlat(flat, shape[0], shape[1], shape[1])
+		return nil
+	}
+
 	v := reflect.ValueOf(values)
 	if v.Len() < 1 {
 		return fmt.Errorf("empty values")
 	}
 
 	rank := 0
-	var shape [2]int
+	var dims [2]int
 
 	for v.Kind() == reflect.Slice {
 		if rank >= 2 {
 			return fmt.Errorf("unsupported rank: %d", rank+1)
 		}
-		shape[rank] = v.Len()
+		dims[rank] = v.Len()
 		v = v.Index(0)
 		rank++
 	}
 
-	if shape[0] < 1 {
+	if dims[0] < 1 {
 		return fmt.Errorf("empty values")
 	}
 
@@ -44,19 +66,143 @@ func (t *Tensor[T]) New(values interface{}) error {
 		return fmt.Errorf("unsupported type: %v", v.Type())
 	}
 
+	if rows, ok := values.([][]T); ok {
+		flat, err := pack(rows)
+		if err != nil {
+			return err
+		}
+		t.setFlat(flat, len(rows), len(rows[0]), len(rows[0]))
+		return nil
+	}
+
 	t.Values = values
-	t.Shape = shape
+	t.Shape = dims
 	t.Rank = rank
 	t.Type = v.Type()
+	t.flat = nil
+	t.stride = 0
+
+	return nil
+}
+
+// pack copies the rows of a matrix into one contiguous buffer.
+func pack[T float32 | float64](rows [][]T) ([]T, error) {
+	cols := len(rows[0])
+	flat := make([]T, len(rows)*cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), cols)
+		}
+		copy(flat[i*cols:], row)
+	}
+
+	return flat, nil
+}
+
+// setFlat makes the tensor a rows x cols matrix over flat, with row i
+// starting at i*stride, and fills Values with views of the rows.
+func (t *Tensor[T]) setFlat(flat []T, rows, cols, stride int) {
+	t.setStorage(flat, rows, cols, stride)
+
+	views := make([][]T, rows)
+	for i := range views {
+		views[i] = flat[i*stride : i*stride+cols : i*stride+cols]
+	}
+	t.Values = views
+}
+
+// setStorage makes the tensor a rows x cols matrix over flat without
+// Values.
+func (t *Tensor[T]) setStorage(flat []T, rows, cols, stride int) {
+	t.Values = nil
+	t.Shape = [2]int{rows, cols}
+	t.Rank = 2
+	t.Type = reflect.TypeOf(T(0))
+	t.flat = flat
+	t.stride = stride
+}
+
+// Row returns row i of a dense matrix.
+func (t *Tensor[T]) Row(i int) []T {
+	if t.flat != nil {
+		return t.flat[i*t.stride : i*t.stride+t.Shape[1] : i*t.stride+t.Shape[1]]
+	}
+	return t.Values.([][]T)[i]
+}
+
+// Flat returns the contiguous row-major buffer of a matrix and its stride,
+// or nil when the matrix was not built with New.
+func (t *Tensor[T]) Flat() ([]T, int) {
+	return t.flat, t.stride
+}
+
+// dense reports whether the tensor is a plain matrix of T, read with Row.
+func (t *Tensor[T]) dense() bool {
+	if t.flat != nil {
+		return true
+	}
+	_, ok := t.Values.([][]T)
+	return ok
+}
+
+// rows returns the rows of a dense matrix, building views when the tensor
+// has no Values.
+func (t *Tensor[T]) rows() [][]T {
+	if rows, ok := t.Values.([][]T); ok {
+		return rows
+	}
+
+	rows := make([][]T, t.Shape[0])
+	for i := range rows {
+		rows[i] = t.Row(i)
+	}
+	return rows
+}
+
+// Normalize scales every row (or the vector) to unit L2 length in place.
+// Zero rows are left unchanged.
+func (t *Tensor[T]) Normalize() error {
+	if t.flat != nil {
+		for i := 0; i < t.Shape[0]; i++ {
+			normalize(t.Row(i))
+		}
+		return nil
+	}
+
+	switch values := t.Values.(type) {
+	case []T:
+		normalize(values)
+	case [][]T:
+		for _, row := range values {
+			normalize(row)
+		}
+	default:
+		return fmt.Errorf("unsupported values: %T", t.Values)
+	}
 
 	return nil
 }
 
+func normalize[T float32 | float64](v []T) {
+	n := norm(v)
+	if n == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= n
+	}
+}
+
 func init() {
 	gob.Register([]float32{})
 	gob.Register([][]float32{})
 	gob.Register([]float64{})
 	gob.Register([][]float64{})
+	gob.Register(&Int8Matrix[float32]{})
+	gob.Register(&Int8Matrix[float64]{})
+	gob.Register([][]Float16{})
+	gob.Register([]uint64{})
+	gob.Register([][]uint64{})
 }
 
 func (t *Tensor[T]) GobEncode() ([]byte, error) {
@@ -68,6 +214,9 @@ func (t *Tensor[T]) GobEncode() ([]byte, error) {
 	}
 
 	data.Values = t.Values
+	if data.Values == nil && t.flat != nil {
+		data.Values = t.rows()
+	}
 	data.Shape = t.Shape
 	data.TypeName = t.Type.Name()
 	data.Rank = t.Rank
@@ -87,23 +236,55 @@ func (t *Tensor[T]) GobDecode(buf []byte) error {
 		return err
 	}
 
-	t.Values = data.Values
-	t.Shape = data.Shape
-	t.Rank = data.Rank
-
+	var typ reflect.Type
 	switch data.TypeName {
 	case "float32":
-		t.Type = reflect.TypeOf(float32(0))
+		typ = reflect.TypeOf(float32(0))
 	case "float64":
-		t.Type = reflect.TypeOf(float64(0))
+		typ = reflect.TypeOf(float64(0))
+	case "int8":
+		typ = reflect.TypeOf(int8(0))
+	case "Float16":
+		typ = reflect.TypeOf(Float16(0))
+	case "uint64":
+		typ = reflect.TypeOf(uint64(0))
 	default:
 		return fmt.Errorf("unsupported type: %s", data.TypeName)
 	}
 
+	if rows, ok := data.Values.([][]T); ok && len(rows) > 0 {
+		flat, err := pack(rows)
+		if err != nil {
+			return err
+		}
+		t.setFlat(flat, len(rows), len(rows[0]), len(rows[0]))
+		return nil
+	}
+
+	t.Values = data.Values
+	t.Shape = data.Shape
+	t.Rank = data.Rank
+	t.Type = typ
+	t.flat = nil
+	t.stride = 0
+
 	return nil
 }
 
 func Export[T float32 | float64](t *Tensor[T], filename string) error {
+	return exportGob(filename, "tensor", t)
+}
+
+func Import[T float32 | float64](filename string) (*Tensor[T], error) {
+	var t Tensor[T]
+	if err := importGob(filename, "tensor", &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func exportGob(filename string, name string, data interface{}) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("error creating file: %v", err)
@@ -111,27 +292,26 @@ func Export[T float32 | float64](t *Tensor[T], filename string) error {
 	defer file.Close()
 
 	encoder := gob.NewEncoder(file)
-	if err := encoder.Encode(t); err != nil {
-		return fmt.Errorf("error encoding tensor: %v", err)
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("error encoding %s: %v", name, err)
 	}
 
 	return nil
 }
 
-func Import[T float32 | float64](filename string) (*Tensor[T], error) {
+func importGob(filename string, name string, data interface{}) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("error opening file: %v", err)
+		return fmt.Errorf("error opening file: %v", err)
 	}
 	defer file.Close()
 
-	var t Tensor[T]
 	decoder := gob.NewDecoder(file)
-	if err := decoder.Decode(&t); err != nil {
-		return nil, fmt.Errorf("error decoding tensor: %v", err)
+	if err := decoder.Decode(data); err != nil {
+		return fmt.Errorf("error decoding %s: %v", name, err)
 	}
 
-	return &t, nil
+	return nil
 }
 
 func gobEncode(data interface{}) ([]byte, error) {
@@ -148,6 +148,187 @@ func TestTensorNewFloat64(t *testing.T) {
 	}
 }
 
+func TestTensorNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  interface{}
+	}{
+		{
+			name:  "1D float32 slice",
+			input: []float32{3.0, 4.0},
+			want:  []float32{0.6, 0.8},
+		},
+		{
+			name:  "2D float32 slice",
+			input: [][]float32{{3.0, 4.0}, {0.0, 2.0}},
+			want:  [][]float32{{0.6, 0.8}, {0.0, 1.0}},
+		},
+		{
+			name:  "Zero row",
+			input: [][]float32{{0.0, 0.0}, {5.0, 0.0}},
+			want:  [][]float32{{0.0, 0.0}, {1.0, 0.0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tensor := &Tensor[float32]{}
+			if err := tensor.New(tt.input); err != nil {
+				t.Fatalf("Tensor.New() error = %v", err)
+			}
+			if err := tensor.Normalize(); err != nil {
+				t.Fatalf("Tensor.Normalize() error = %v", err)
+			}
+			if !reflect.DeepEqual(tensor.Values, tt.want) {
+				t.Errorf("Tensor.Normalize() Values = %v, want %v", tensor.Values, tt.want)
+			}
+		})
+	}
+
+	t.Run("Mismatched type", func(t *testing.T) {
+		tensor := &Tensor[float32]{Values: []float64{1.0}}
+		if err := tensor.Normalize(); err == nil {
+			t.Error("Expected error for mismatched values type, got nil")
+		}
+	})
+}
+
+func TestTensorFlat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{}
+		shape   []int
+		want    [][]float32
+		wantErr bool
+	}{
+		{
+			name:  "Flat with shape",
+			input: []float32{1, 2, 3, 4, 5, 6},
+			shape: []int{2, 3},
+			want:  [][]float32{{1, 2, 3}, {4, 5, 6}},
+		},
+		{
+			name:  "Rows are packed",
+			input: [][]float32{{1, 2}, {3, 4}, {5, 6}},
+			want:  [][]float32{{1, 2}, {3, 4}, {5, 6}},
+		},
+		{
+			name:    "Length does not match shape",
+			input:   []float32{1, 2, 3},
+			shape:   []int{2, 2},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid shape",
+			input:   []float32{1, 2},
+			shape:   []int{2},
+			wantErr: true,
+		},
+		{
+			name:    "Ragged rows",
+			input:   [][]float32{{1, 2}, {3}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tensor := &Tensor[float32]{}
+			err := tensor.New(tt.input, tt.shape...)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Tensor.New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tensor.Rank != 2 || tensor.Shape != [2]int{len(tt.want), len(tt.want[0])} {
+				t.Errorf("Tensor.New() Shape = %v, Rank = %d", tensor.Shape, tensor.Rank)
+			}
+			if !reflect.DeepEqual(tensor.Values, tt.want) {
+				t.Errorf("Tensor.New() Values = %v, want %v", tensor.Values, tt.want)
+			}
+			for i, row := range tt.want {
+				if !reflect.DeepEqual(tensor.Row(i), row) {
+					t.Errorf("Tensor.Row(%d) = %v, want %v", i, tensor.Row(i), row)
+				}
+			}
+
+			flat, stride := tensor.Flat()
+			if stride != len(tt.want[0]) || len(flat) != len(tt.want)*stride {
+				t.Fatalf("Tensor.Flat() len = %d, stride = %d", len(flat), stride)
+			}
+			flat[stride] = 42
+			if tensor.Row(1)[0] != 42 || tensor.Values.([][]float32)[1][0] != 42 {
+				t.Error("Row and Values do not share the flat buffer")
+			}
+		})
+	}
+
+	t.Run("Ragged rows leave tensor unchanged", func(t *testing.T) {
+		tensor := &Tensor[float32]{}
+		if err := tensor.New([][]float32{{1, 2}, {3, 4}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tensor.New([][]float32{{1, 2, 3}, {4}}); err == nil {
+			t.Fatal("expected error for ragged rows, got nil")
+		}
+		if tensor.Shape != [2]int{2, 2} || !reflect.DeepEqual(tensor.Values, [][]float32{{1, 2}, {3, 4}}) {
+			t.Errorf("tensor changed: Shape = %v, Values = %v", tensor.Shape, tensor.Values)
+		}
+	})
+
+	t.Run("Gob round trip", func(t *testing.T) {
+		tensor := &Tensor[float32]{}
+		if err := tensor.New([]float32{1, 2, 3, 4}, 2, 2); err != nil {
+			t.Fatal(err)
+		}
+		buf, err := tensor.GobEncode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := &Tensor[float32]{}
+		if err := decoded.GobDecode(buf); err != nil {
+			t.Fatal(err)
+		}
+		if flat, _ := decoded.Flat(); !reflect.DeepEqual(flat, []float32{1, 2, 3, 4}) {
+			t.Errorf("decoded Flat() = %v", flat)
+		}
+	})
+
+	t.Run("Search matches rows", func(t *testing.T) {
+		rows := [][]float32{{0, 0}, {1, 1}, {5, 5}, {2, 3}}
+		flat := &Tensor[float32]{}
+		if err := flat.New([]float32{0, 0, 1, 1, 5, 5, 2, 3}, 4, 2); err != nil {
+			t.Fatal(err)
+		}
+		packed := &Tensor[float32]{}
+		if err := packed.New(rows); err != nil {
+			t.Fatal(err)
+		}
+		query := &Tensor[float32]{}
+		if err := query.New([]float32{1.5, 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, metric := range []int{L1, L2, Cosine} {
+			want, err := (&Search[float32]{Data: packed, Query: query}).metric(2, metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := (&Search[float32]{Data: flat, Query: query}).metric(2, metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("metric %d: got %v, want %v", metric, got, want)
+			}
+		}
+	})
+}
+
 func BenchmarkTensorNew(b *testing.B) {
 	benchmarks := []struct {
 		name  string